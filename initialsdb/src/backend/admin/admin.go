@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"app.root/guards"
+)
+
+// Admin endpoints live under /api/admin/ and are guarded by
+// guards.AdminGuard only (see routes.RegisterRoutes).
+
+// Actions recorded in moderation_events.action.
+const (
+	ActionHide      = "hide"
+	ActionUnhide    = "unhide"
+	ActionBan       = "ban"
+	ActionUnban     = "unban"
+	ActionShadowBan = "shadow_ban"
+	ActionBulk      = "bulk_hide"
+	ActionApprove   = "approve"
+	ActionReject    = "reject"
+	ActionPurge     = "purge" // CLI purge; the row outlives the listing
+)
+
+const maxReasonLen = 500
+
+func pathID(r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func normalizeReason(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) > maxReasonLen {
+		return "", false
+	}
+	return s, true
+}
+
+func decodeIPHash(s string) ([]byte, bool) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != sha256.Size {
+		return nil, false
+	}
+	return b, true
+}
+
+// resolveIPHash accepts exactly one of a hex ip_hash (as shown by the
+// admin search) or a raw ip, hashed the same way as in CreateHandler.
+func resolveIPHash(ipHash, ip, salt string) ([]byte, bool) {
+	switch {
+	case ipHash != "" && ip == "":
+		return decodeIPHash(ipHash)
+
+	case ip != "" && ipHash == "":
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return nil, false
+		}
+		return guards.HashIP(parsed.String(), salt), true
+	}
+
+	return nil, false
+}
+
+// ParseIPOrHash takes either form from an operator: a 64-char hex
+// ip_hash or a raw ip.
+func ParseIPOrHash(s, salt string) ([]byte, bool) {
+	if b, ok := decodeIPHash(s); ok {
+		return b, true
+	}
+	return resolveIPHash("", s, salt)
+}
@@ -0,0 +1,203 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// BansHandler lists and creates bans:
+//
+//	GET  /api/admin/bans
+//	POST /api/admin/bans     {"ip_hash" | "ip", "reason", "expires_in_secs", "shadow"}
+//
+// A ban is keyed by ip_hash; a raw ip is hashed with the server salt.
+// A shadow ban lets the source keep posting: its listings are stored
+// hidden but still shown to that same source.
+type BansHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+	Salt   string
+	Bans   *guards.BanGuard // refreshed after every change
+}
+
+// BanHandler removes a ban:
+//
+//	DELETE /api/admin/bans/{ip_hash}
+type BanHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+	Bans   *guards.BanGuard
+}
+
+type banRequest struct {
+	IPHash        string `json:"ip_hash"`
+	IP            string `json:"ip"`
+	Reason        string `json:"reason"`
+	ExpiresInSecs int64  `json:"expires_in_secs"` // 0 = permanent
+	Shadow        bool   `json:"shadow"`
+}
+
+type banResult struct {
+	IPHash    string     `json:"ip_hash"`
+	Reason    string     `json:"reason"`
+	Actor     string     `json:"actor"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Shadow    bool       `json:"shadow"`
+}
+
+func (h *BansHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	if r.Method == http.MethodGet {
+		h.list(w, r)
+		return
+	}
+
+	var req banRequest
+	if err := httpjson.Decode(r, &req); err != nil {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid json body")
+		return
+	}
+
+	ipHash, ok := resolveIPHash(req.IPHash, req.IP, h.Salt)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "exactly one of ip_hash or ip is required")
+		return
+	}
+
+	reason, ok := normalizeReason(req.Reason)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "reason too long")
+		return
+	}
+
+	if req.ExpiresInSecs < 0 {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid expires_in_secs")
+		return
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresInSecs > 0 {
+		expiresAt = sql.NullTime{
+			Time:  time.Now().Add(time.Duration(req.ExpiresInSecs) * time.Second),
+			Valid: true,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	ban, err := Ban(ctx, db.NewStore(h.DB), db.UpsertBanParams{
+		IpHash:    ipHash,
+		Reason:    reason,
+		Actor:     guards.AdminActor(r),
+		ExpiresAt: expiresAt,
+		Shadow:    req.Shadow,
+	})
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	refreshBans(r.Context(), h.Bans)
+
+	httpjson.WriteCreated(w, toBanResult(ban))
+}
+
+func (h *BansHandler) list(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	res, err := db.New(h.DB).ListActiveBans(ctx)
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	items := make([]banResult, 0, len(res))
+	for _, b := range res {
+		items = append(items, toBanResult(b))
+	}
+
+	httpjson.WriteOK(w, map[string][]banResult{
+		"items": items,
+	})
+}
+
+func (h *BanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	ipHash, ok := decodeIPHash(r.PathValue("ip_hash"))
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid ip_hash")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	err := Unban(ctx, db.NewStore(h.DB), ipHash, guards.AdminActor(r))
+	if errors.Is(err, sql.ErrNoRows) {
+		httpjson.NotFound(w, "NOT_FOUND", "ban not found")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	refreshBans(r.Context(), h.Bans)
+
+	httpjson.WriteNoContent(w)
+}
+
+func refreshBans(ctx context.Context, g *guards.BanGuard) {
+	if g == nil {
+		return
+	}
+	// The change is committed; a failed refresh is caught up by the periodic one.
+	if err := g.Refresh(ctx); err != nil {
+		fmt.Println("ban list refresh failed:", err)
+	}
+}
+
+func toBanResult(b db.Ban) banResult {
+	return banResult{
+		IPHash:    hex.EncodeToString(b.IpHash),
+		Reason:    b.Reason,
+		Actor:     b.Actor,
+		CreatedAt: b.CreatedAt,
+		ExpiresAt: timePtr(b.ExpiresAt),
+		Shadow:    b.Shadow,
+	}
+}
@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// BulkHideHandler hides every visible listing of one source:
+//
+//	POST /api/admin/listings/bulk-hide
+//	{"ip_hash" | "ip", "since", "until", "dry_run", "reason"}
+//
+// since/until are optional RFC 3339 bounds on created_at [since, until).
+// With dry_run nothing changes; the IDs that would be hidden are returned.
+type BulkHideHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+	Salt   string
+}
+
+type bulkHideRequest struct {
+	IPHash string     `json:"ip_hash"`
+	IP     string     `json:"ip"`
+	Since  *time.Time `json:"since"`
+	Until  *time.Time `json:"until"`
+	DryRun bool       `json:"dry_run"`
+	Reason string     `json:"reason"`
+}
+
+type bulkHideResponse struct {
+	IPHash string  `json:"ip_hash"`
+	DryRun bool    `json:"dry_run"`
+	Count  int     `json:"count"`
+	IDs    []int64 `json:"ids"`
+}
+
+func (h *BulkHideHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	var req bulkHideRequest
+	if err := httpjson.Decode(r, &req); err != nil {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid json body")
+		return
+	}
+
+	ipHash, ok := resolveIPHash(req.IPHash, req.IP, h.Salt)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "exactly one of ip_hash or ip is required")
+		return
+	}
+
+	if req.Since != nil && req.Until != nil && !req.Since.Before(*req.Until) {
+		httpjson.BadRequest(w, "INVALID_INPUT", "since must be before until")
+		return
+	}
+
+	reason, ok := normalizeReason(req.Reason)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "reason too long")
+		return
+	}
+
+	since := nullTime(req.Since)
+	until := nullTime(req.Until)
+
+	// A flood can be thousands of rows: writer timeout, not reader.
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	store := db.NewStore(h.DB)
+	actor := guards.AdminActor(r)
+
+	var (
+		ids []int64
+		err error
+	)
+
+	if req.DryRun {
+		ids, err = store.ListListingIDsByIP(ctx, db.ListListingIDsByIPParams{
+			IpHash: ipHash,
+			Since:  since,
+			Until:  until,
+		})
+	} else {
+		// One statement: the hide and its audit rows commit together.
+		ids, err = store.HideListingsByIP(ctx, db.HideListingsByIPParams{
+			IpHash: ipHash,
+			Since:  since,
+			Until:  until,
+			Actor:  actor,
+			Action: ActionBulk,
+			Reason: reason,
+		})
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	httpjson.WriteOK(w, bulkHideResponse{
+		IPHash: hex.EncodeToString(ipHash),
+		DryRun: req.DryRun,
+		Count:  len(ids),
+		IDs:    ids,
+	})
+}
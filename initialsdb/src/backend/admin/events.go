@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app.root/cursor"
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// EventsHandler pages through the moderation audit log, newest first:
+//
+//	GET /api/admin/events?listing_id=&limit=&cursor=
+type EventsHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+}
+
+type eventResult struct {
+	ID        int64     `json:"id"`
+	ListingID *int64    `json:"listing_id,omitempty"`
+	IPHash    string    `json:"ip_hash,omitempty"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type eventsResponse struct {
+	Items      []eventResult `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	var listingID sql.NullInt64
+	if v := r.URL.Query().Get("listing_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing_id")
+			return
+		}
+		listingID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	limit := int32(30)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
+			limit = int32(v)
+		}
+	}
+
+	after := r.URL.Query().Get("cursor")
+	store := db.NewStore(h.DB)
+
+	var (
+		res []db.ModerationEvent
+		err error
+	)
+
+	if after == "" {
+		res, err = store.ListModerationEventsFirstPage(
+			ctx,
+			db.ListModerationEventsFirstPageParams{
+				ListingID: listingID,
+				RowLimit:  limit,
+			},
+		)
+	} else {
+		createdAt, id, ok := cursor.Decode(after)
+		if !ok {
+			httpjson.BadRequest(w, "INVALID_INPUT", "invalid cursor")
+			return
+		}
+
+		res, err = store.ListModerationEventsAfterCursor(
+			ctx,
+			db.ListModerationEventsAfterCursorParams{
+				ListingID: listingID,
+				CreatedAt: createdAt,
+				ID:        id,
+				RowLimit:  limit,
+			},
+		)
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	rows := make([]eventResult, 0, len(res))
+	for _, e := range res {
+		item := eventResult{
+			ID:        e.ID,
+			IPHash:    hex.EncodeToString(e.IpHash),
+			Actor:     e.Actor,
+			Action:    e.Action,
+			Reason:    e.Reason,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.ListingID.Valid {
+			id := e.ListingID.Int64
+			item.ListingID = &id
+		}
+		rows = append(rows, item)
+	}
+
+	resp := eventsResponse{
+		Items: rows,
+	}
+
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		resp.NextCursor = cursor.Encode(last.CreatedAt, last.ID)
+	}
+
+	httpjson.WriteOK(w, resp)
+}
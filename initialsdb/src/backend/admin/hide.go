@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// HideHandler serves both
+//
+//	POST /api/admin/listings/{id}/hide
+//	POST /api/admin/listings/{id}/unhide
+//
+// depending on Hide.
+type HideHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+	Hide   bool
+}
+
+type moderationRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *HideHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	id, ok := pathID(r)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing id")
+		return
+	}
+
+	// Body is optional: {"reason": "..."}
+	var req moderationRequest
+	if r.ContentLength != 0 {
+		if err := httpjson.Decode(r, &req); err != nil {
+			httpjson.BadRequest(w, "INVALID_INPUT", "invalid json body")
+			return
+		}
+	}
+
+	reason, ok := normalizeReason(req.Reason)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "reason too long")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	resp, err := SetHidden(ctx, db.NewStore(h.DB), id, h.Hide, guards.AdminActor(r), reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpjson.NotFound(w, "NOT_FOUND", "listing not found")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	httpjson.WriteOK(w, resp)
+}
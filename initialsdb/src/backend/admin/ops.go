@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"app.root/db"
+)
+
+/*
+Moderation operations shared by the HTTP handlers and the offline
+subcommands in server/cli.go. Each one runs in a single transaction
+together with its moderation_events row, so both paths leave the
+same audit trail.
+*/
+
+// ListingState is what a hide/unhide leaves behind.
+type ListingState struct {
+	ID       int64      `json:"id"`
+	IsHidden bool       `json:"is_hidden"`
+	HiddenAt *time.Time `json:"hidden_at,omitempty"`
+}
+
+// SetHidden hides or unhides one listing. sql.ErrNoRows when the
+// listing does not exist.
+func SetHidden(ctx context.Context, store *db.Store, id int64, hide bool, actor, reason string) (ListingState, error) {
+	var state ListingState
+
+	err := store.ExecTx(ctx, func(tx *db.Store) error {
+		action := ActionUnhide
+
+		if hide {
+			action = ActionHide
+			row, err := tx.HideListing(ctx, id)
+			if err != nil {
+				return err
+			}
+			state = ListingState{ID: row.ID, IsHidden: row.IsHidden, HiddenAt: timePtr(row.HiddenAt)}
+		} else {
+			row, err := tx.UnhideListing(ctx, id)
+			if err != nil {
+				return err
+			}
+			state = ListingState{ID: row.ID, IsHidden: row.IsHidden, HiddenAt: timePtr(row.HiddenAt)}
+		}
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			ListingID: sql.NullInt64{Int64: id, Valid: true},
+			Actor:     actor,
+			Action:    action,
+			Reason:    reason,
+		})
+	})
+
+	return state, err
+}
+
+// Ban creates or replaces the ban on arg.IpHash.
+func Ban(ctx context.Context, store *db.Store, arg db.UpsertBanParams) (db.Ban, error) {
+	var ban db.Ban
+
+	err := store.ExecTx(ctx, func(tx *db.Store) error {
+		var err error
+		ban, err = tx.UpsertBan(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		action := ActionBan
+		if arg.Shadow {
+			action = ActionShadowBan
+		}
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			IpHash: arg.IpHash,
+			Actor:  arg.Actor,
+			Action: action,
+			Reason: arg.Reason,
+		})
+	})
+
+	return ban, err
+}
+
+// Unban lifts the ban on ipHash. sql.ErrNoRows when there is none.
+func Unban(ctx context.Context, store *db.Store, ipHash []byte, actor string) error {
+	return store.ExecTx(ctx, func(tx *db.Store) error {
+		n, err := tx.DeleteBan(ctx, ipHash)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			IpHash: ipHash,
+			Actor:  actor,
+			Action: ActionUnban,
+		})
+	})
+}
@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app.root/cursor"
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// QueueHandler pages through listings held for review, oldest first:
+//
+//	GET /api/admin/queue?limit=&cursor=
+type QueueHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+}
+
+// ReviewHandler decides on one held listing:
+//
+//	POST /api/admin/queue/{id}/approve
+//	POST /api/admin/queue/{id}/reject
+//
+// depending on Approve. Reject hides the listing.
+type ReviewHandler struct {
+	DB      *sql.DB
+	Guards  []guards.Guard
+	Approve bool
+}
+
+type pendingResult struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	IPHash    string    `json:"ip_hash"`
+	LinkCount int32     `json:"link_count"`
+}
+
+type queueResponse struct {
+	Items      []pendingResult `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (h *QueueHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	limit := int32(30)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
+			limit = int32(v)
+		}
+	}
+
+	after := r.URL.Query().Get("cursor")
+	store := db.NewStore(h.DB)
+
+	var rows []pendingResult
+
+	if after == "" {
+		res, err := store.ListPendingFirstPage(ctx, limit)
+		if err != nil {
+			httpjson.InternalError(w, "db error")
+			return
+		}
+
+		rows = make([]pendingResult, 0, len(res))
+		for _, r := range res {
+			rows = append(rows, pendingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				CreatedAt: r.CreatedAt,
+				IPHash:    hex.EncodeToString(r.IpHash),
+				LinkCount: r.LinkCount.Int32,
+			})
+		}
+	} else {
+		createdAt, id, ok := cursor.Decode(after)
+		if !ok {
+			httpjson.BadRequest(w, "INVALID_INPUT", "invalid cursor")
+			return
+		}
+
+		res, err := store.ListPendingAfterCursor(
+			ctx,
+			db.ListPendingAfterCursorParams{
+				CreatedAt: createdAt,
+				ID:        id,
+				Limit:     limit,
+			},
+		)
+		if err != nil {
+			httpjson.InternalError(w, "db error")
+			return
+		}
+
+		rows = make([]pendingResult, 0, len(res))
+		for _, r := range res {
+			rows = append(rows, pendingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				CreatedAt: r.CreatedAt,
+				IPHash:    hex.EncodeToString(r.IpHash),
+				LinkCount: r.LinkCount.Int32,
+			})
+		}
+	}
+
+	resp := queueResponse{
+		Items: rows,
+	}
+
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		resp.NextCursor = cursor.Encode(last.CreatedAt, last.ID)
+	}
+
+	httpjson.WriteOK(w, resp)
+}
+
+func (h *ReviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	id, ok := pathID(r)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing id")
+		return
+	}
+
+	// Body is optional: {"reason": "..."}
+	var req moderationRequest
+	if r.ContentLength != 0 {
+		if err := httpjson.Decode(r, &req); err != nil {
+			httpjson.BadRequest(w, "INVALID_INPUT", "invalid json body")
+			return
+		}
+	}
+
+	reason, ok := normalizeReason(req.Reason)
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "reason too long")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	store := db.NewStore(h.DB)
+
+	err := store.ExecTx(ctx, func(tx *db.Store) error {
+		action := ActionReject
+		update := tx.RejectPendingListing
+		if h.Approve {
+			action = ActionApprove
+			update = tx.ApprovePendingListing
+		}
+
+		n, err := update(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			ListingID: sql.NullInt64{Int64: id, Valid: true},
+			Actor:     guards.AdminActor(r),
+			Action:    action,
+			Reason:    reason,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpjson.NotFound(w, "NOT_FOUND", "listing not in review queue")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	httpjson.WriteNoContent(w)
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app.root/cursor"
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// SearchHandler is the moderator view of /api/listings/search:
+// same keyset pagination, but it can include hidden and pending rows
+// and exposes the moderation columns and ip_hash.
+type SearchHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+}
+
+type listingResult struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	IsHidden  bool       `json:"is_hidden"`
+	HiddenAt  *time.Time `json:"hidden_at,omitempty"`
+	IsPending bool       `json:"is_pending"`
+	CreatedAt time.Time  `json:"created_at"`
+	IPHash    string     `json:"ip_hash"`
+}
+
+type searchResponse struct {
+	Items      []listingResult `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Unauthorized(w, "UNAUTHORIZED", "admin authentication required")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	includeHidden, _ := strconv.ParseBool(r.URL.Query().Get("include_hidden"))
+
+	limit := int32(30)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
+			limit = int32(v)
+		}
+	}
+
+	after := r.URL.Query().Get("cursor")
+	store := db.NewStore(h.DB)
+
+	var rows []listingResult
+
+	if after == "" {
+		res, err := store.AdminSearchListingsFirstPage(
+			ctx,
+			db.AdminSearchListingsFirstPageParams{
+				IncludeHidden: includeHidden,
+				Q:             q,
+				RowLimit:      limit,
+			},
+		)
+		if err != nil {
+			httpjson.InternalError(w, "db error")
+			return
+		}
+
+		rows = make([]listingResult, 0, len(res))
+		for _, r := range res {
+			rows = append(rows, listingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				IsHidden:  r.IsHidden,
+				HiddenAt:  timePtr(r.HiddenAt),
+				IsPending: r.IsPending,
+				CreatedAt: r.CreatedAt,
+				IPHash:    hex.EncodeToString(r.IpHash),
+			})
+		}
+	} else {
+		createdAt, id, ok := cursor.Decode(after)
+		if !ok {
+			httpjson.BadRequest(w, "INVALID_INPUT", "invalid cursor")
+			return
+		}
+
+		res, err := store.AdminSearchListingsAfterCursor(
+			ctx,
+			db.AdminSearchListingsAfterCursorParams{
+				IncludeHidden: includeHidden,
+				Q:             q,
+				CreatedAt:     createdAt,
+				ID:            id,
+				RowLimit:      limit,
+			},
+		)
+		if err != nil {
+			httpjson.InternalError(w, "db error")
+			return
+		}
+
+		rows = make([]listingResult, 0, len(res))
+		for _, r := range res {
+			rows = append(rows, listingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				IsHidden:  r.IsHidden,
+				HiddenAt:  timePtr(r.HiddenAt),
+				IsPending: r.IsPending,
+				CreatedAt: r.CreatedAt,
+				IPHash:    hex.EncodeToString(r.IpHash),
+			})
+		}
+	}
+
+	resp := searchResponse{
+		Items: rows,
+	}
+
+	if len(rows) == int(limit) {
+		last := rows[len(rows)-1]
+		resp.NextCursor = cursor.Encode(last.CreatedAt, last.ID)
+	}
+
+	httpjson.WriteOK(w, resp)
+}
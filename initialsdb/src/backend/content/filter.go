@@ -0,0 +1,99 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+//
+// ──────────────────────────────────────────────
+// Config
+// ──────────────────────────────────────────────
+//
+
+type FilterConfig struct {
+	Enable bool
+	Path   string        // JSON rules file
+	Reload time.Duration // how often the file mtime is checked, 0 = never
+}
+
+//
+// ──────────────────────────────────────────────
+// Filter (hot-reloadable Policy)
+// ──────────────────────────────────────────────
+//
+
+// Filter holds the current Policy and swaps it when the rules file
+// changes on disk. A broken edit is logged and the previous policy
+// stays in force.
+type Filter struct {
+	path   string
+	policy atomic.Pointer[Policy]
+	mtime  time.Time
+}
+
+// NewFilter loads the rules file once and starts watching it.
+// A disabled filter is nil, which allows everything.
+func NewFilter(cfg FilterConfig) (*Filter, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+
+	f := &Filter{path: cfg.Path}
+
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Reload > 0 {
+		go f.watch(cfg.Reload)
+	}
+
+	return f, nil
+}
+
+func (f *Filter) Evaluate(body string) Verdict {
+	if f == nil {
+		return Verdict{}
+	}
+	return f.policy.Load().Evaluate(body)
+}
+
+func (f *Filter) reload() error {
+	st, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("stat content policy: %w", err)
+	}
+
+	p, err := LoadPolicy(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.policy.Store(p)
+	f.mtime = st.ModTime()
+	return nil
+}
+
+func (f *Filter) watch(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		st, err := os.Stat(f.path)
+		if err != nil || st.ModTime().Equal(f.mtime) {
+			continue
+		}
+
+		if err := f.reload(); err != nil {
+			fmt.Println("content policy reload failed, keeping previous:", err)
+			// do not retry the same broken file every tick
+			f.mtime = st.ModTime()
+			continue
+		}
+
+		fmt.Println("content policy reloaded:", f.path)
+	}
+}
@@ -0,0 +1,109 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+)
+
+/*
+Listing language → Postgres text search configuration.
+
+Clients speak ISO 639-1 codes ("en"); the database stores the
+regconfig name ("english") in listings.lang, and body_tsv is built
+with it (plus 'simple', so exact words keep matching). Only
+configurations shipped with stock Postgres are listed.
+
+DetectLang is a cheap guess for posts that do not say: Cyrillic
+script → Russian, otherwise the language whose common stopwords
+appear most often. When nothing stands out the answer is "simple"
+(no stemming), which is what every listing used before.
+*/
+
+const LangSimple = "simple"
+
+var textSearchConfigs = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fi": "finnish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pt": "portuguese",
+	"ro": "romanian",
+	"ru": "russian",
+	"sv": "swedish",
+	"tr": "turkish",
+}
+
+// TextSearchConfig maps an ISO 639-1 code (or "simple") to the
+// regconfig name. ok is false for anything unsupported.
+func TextSearchConfig(code string) (string, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == LangSimple {
+		return LangSimple, true
+	}
+	cfg, ok := textSearchConfigs[code]
+	return cfg, ok
+}
+
+// Stopwords per configuration; short, frequent, and rarely shared.
+// Keep in sync with the backfill in migrations/012_listings_lang.sql.
+var stopwords = map[string][]string{
+	"english":    {"the", "and", "with", "for", "is", "are", "this", "that", "of", "to"},
+	"german":     {"der", "die", "das", "und", "ist", "nicht", "mit", "ein", "eine", "für"},
+	"french":     {"le", "la", "les", "et", "est", "une", "des", "pour", "avec", "dans"},
+	"spanish":    {"el", "los", "las", "y", "es", "una", "para", "con", "del", "por"},
+	"italian":    {"il", "gli", "della", "che", "è", "una", "per", "con", "sono", "non"},
+	"portuguese": {"o", "os", "as", "e", "é", "uma", "para", "com", "não", "do"},
+	"dutch":      {"de", "het", "een", "en", "is", "van", "voor", "met", "niet", "op"},
+}
+
+const minStopwordHits = 2
+
+func DetectLang(body string) string {
+	var cyrillic, letters int
+	for _, r := range body {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.Is(unicode.Cyrillic, r) {
+				cyrillic++
+			}
+		}
+	}
+	if letters > 0 && cyrillic*2 > letters {
+		return "russian"
+	}
+
+	counts := make(map[string]int, len(stopwords))
+	for _, w := range strings.FieldsFunc(strings.ToLower(body), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for cfg, list := range stopwords {
+			for _, s := range list {
+				if w == s {
+					counts[cfg]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestHits, tie := LangSimple, 0, false
+	for cfg, n := range counts {
+		switch {
+		case n > bestHits:
+			best, bestHits, tie = cfg, n, false
+		case n == bestHits:
+			tie = true
+		}
+	}
+
+	if bestHits < minStopwordHits || tie {
+		return LangSimple
+	}
+	return best
+}
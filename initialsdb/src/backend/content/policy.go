@@ -0,0 +1,252 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+Content policy for new listings, loaded from a JSON file:
+
+	{
+	  "max_body_length": 2000,
+	  "max_links": 2,
+	  "allowed_domains": [],
+	  "denied_domains": ["bit.ly"],
+	  "blocked_terms": ["casino"],
+	  "blocked_patterns": ["(?i)crypto\\s+giveaway"],
+	  "actions": {"TOO_MANY_LINKS": "quarantine"}
+	}
+
+Zero / empty fields disable a rule. Each violated rule yields a reason
+code; "actions" maps a reason code to "reject" or "quarantine"
+(default "reject"). A reject always wins over a quarantine. Unknown
+reason codes in "actions" are an error, so a typo cannot quietly
+fall back to reject.
+
+Links start where the listings.has_links / link_count generated
+columns see one, (https?://|www\.) case-insensitive, so HasLinks
+agrees with has_links. Counting differs: here a link runs to the end
+of its host, so "https://www.x.com" is one link, while link_count
+counts both prefixes (2). max_links is checked against the former.
+*/
+
+// Reason codes
+const (
+	ReasonBodyTooLong      = "BODY_TOO_LONG"
+	ReasonTooManyLinks     = "TOO_MANY_LINKS"
+	ReasonDomainDenied     = "DOMAIN_DENIED"
+	ReasonDomainNotAllowed = "DOMAIN_NOT_ALLOWED"
+	ReasonBlockedTerm      = "BLOCKED_TERM"
+	ReasonBlockedPattern   = "BLOCKED_PATTERN"
+)
+
+var reasons = map[string]bool{
+	ReasonBodyTooLong:      true,
+	ReasonTooManyLinks:     true,
+	ReasonDomainDenied:     true,
+	ReasonDomainNotAllowed: true,
+	ReasonBlockedTerm:      true,
+	ReasonBlockedPattern:   true,
+}
+
+type Action int
+
+const (
+	Allow Action = iota
+	Quarantine
+	Reject
+)
+
+type Verdict struct {
+	Action Action
+	Reason string // reason code, "" when allowed
+}
+
+type Rules struct {
+	MaxBodyLength   int               `json:"max_body_length"`
+	MaxLinks        int               `json:"max_links"`
+	AllowedDomains  []string          `json:"allowed_domains"`
+	DeniedDomains   []string          `json:"denied_domains"`
+	BlockedTerms    []string          `json:"blocked_terms"`
+	BlockedPatterns []string          `json:"blocked_patterns"`
+	Actions         map[string]string `json:"actions"`
+}
+
+// Policy is a compiled, immutable Rules.
+type Policy struct {
+	maxBodyLength  int
+	maxLinks       int
+	allowedDomains []string
+	deniedDomains  []string
+	terms          *regexp.Regexp // nil = no blocked terms
+	patterns       []*regexp.Regexp
+	actions        map[string]Action
+}
+
+var linkRe = regexp.MustCompile(`(?i)(?:https?://|www\.)([^\s/?#<>"'\\]*)`)
+
+// HasLinks mirrors the listings.has_links generated column.
+func HasLinks(body string) bool {
+	return linkRe.MatchString(body)
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read content policy: %w", err)
+	}
+
+	var rules Rules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("parse content policy %s: %w", path, err)
+	}
+
+	return Compile(rules)
+}
+
+func Compile(rules Rules) (*Policy, error) {
+	p := &Policy{
+		maxBodyLength:  rules.MaxBodyLength,
+		maxLinks:       rules.MaxLinks,
+		allowedDomains: normalizeDomains(rules.AllowedDomains),
+		deniedDomains:  normalizeDomains(rules.DeniedDomains),
+		actions:        make(map[string]Action, len(rules.Actions)),
+	}
+
+	var quoted []string
+	for _, t := range rules.BlockedTerms {
+		if t = strings.TrimSpace(t); t != "" {
+			quoted = append(quoted, regexp.QuoteMeta(t))
+		}
+	}
+	if len(quoted) > 0 {
+		// Whole words only (Unicode-aware, unlike \b).
+		p.terms = regexp.MustCompile(
+			`(?i)(?:^|[^\p{L}\p{N}])(?:` + strings.Join(quoted, "|") + `)(?:$|[^\p{L}\p{N}])`,
+		)
+	}
+
+	for _, s := range rules.BlockedPatterns {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("blocked pattern %q: %w", s, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+
+	for reason, action := range rules.Actions {
+		if !reasons[reason] {
+			return nil, fmt.Errorf("actions: unknown reason code %q", reason)
+		}
+
+		switch strings.ToLower(action) {
+		case "reject":
+			p.actions[reason] = Reject
+		case "quarantine":
+			p.actions[reason] = Quarantine
+		default:
+			return nil, fmt.Errorf("action for %s: unknown %q", reason, action)
+		}
+	}
+
+	return p, nil
+}
+
+// Evaluate checks an already trimmed body against every rule.
+func (p *Policy) Evaluate(body string) Verdict {
+	var verdict Verdict
+
+	flag := func(reason string) {
+		action, ok := p.actions[reason]
+		if !ok {
+			action = Reject
+		}
+		if action > verdict.Action {
+			verdict = Verdict{Action: action, Reason: reason}
+		}
+	}
+
+	if p.maxBodyLength > 0 && utf8.RuneCountInString(body) > p.maxBodyLength {
+		flag(ReasonBodyTooLong)
+	}
+
+	links := linkRe.FindAllStringSubmatch(body, -1)
+
+	if p.maxLinks > 0 && len(links) > p.maxLinks {
+		flag(ReasonTooManyLinks)
+	}
+
+	for _, m := range links {
+		host := normalizeHost(m[1])
+
+		if matchDomain(host, p.deniedDomains) {
+			flag(ReasonDomainDenied)
+		}
+
+		if len(p.allowedDomains) > 0 && !matchDomain(host, p.allowedDomains) {
+			flag(ReasonDomainNotAllowed)
+		}
+	}
+
+	if p.terms != nil && p.terms.MatchString(body) {
+		flag(ReasonBlockedTerm)
+	}
+
+	for _, re := range p.patterns {
+		if re.MatchString(body) {
+			flag(ReasonBlockedPattern)
+			break
+		}
+	}
+
+	return verdict
+}
+
+//
+// ──────────────────────────────────────────────
+// Domains
+// ──────────────────────────────────────────────
+//
+
+func normalizeDomains(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, d := range in {
+		if d = normalizeHost(d); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// normalizeHost lowercases and strips www., port, userinfo
+// and trailing punctuation ("example.com)." → "example.com").
+func normalizeHost(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	if i := strings.LastIndex(h, "@"); i >= 0 {
+		h = h[i+1:]
+	}
+	if i := strings.Index(h, ":"); i >= 0 {
+		h = h[:i]
+	}
+	h = strings.TrimRight(h, ".,;:!?)]}")
+	h = strings.TrimPrefix(h, "www.")
+	return h
+}
+
+// matchDomain reports whether host is one of domains or a subdomain of one.
+func matchDomain(host string, domains []string) bool {
+	if host == "" {
+		return false
+	}
+	for _, d := range domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
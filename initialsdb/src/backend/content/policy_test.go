@@ -0,0 +1,85 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	p, err := Compile(Rules{
+		MaxBodyLength:   40,
+		MaxLinks:        1,
+		DeniedDomains:   []string{"bit.ly"},
+		BlockedTerms:    []string{"casino"},
+		BlockedPatterns: []string{`(?i)crypto\s+giveaway`},
+		Actions: map[string]string{
+			ReasonTooManyLinks: "quarantine",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		action Action
+		reason string
+	}{
+		{"clean", "red bicycle for sale", Allow, ""},
+		{"too long", strings.Repeat("a", 41), Reject, ReasonBodyTooLong},
+		{"long in bytes only", strings.Repeat("ä", 40), Allow, ""},
+		{"one link", "see https://example.com", Allow, ""},
+		{"www after scheme is one link", "see https://www.example.com", Allow, ""},
+		{"too many links", "a.com www.a.com http://b.com", Quarantine, ReasonTooManyLinks},
+		{"denied domain", "go to https://bit.ly/x", Reject, ReasonDomainDenied},
+		{"denied subdomain", "go to www.s.bit.ly", Reject, ReasonDomainDenied},
+		{"blocked term", "best Casino in town", Reject, ReasonBlockedTerm},
+		{"term inside a word", "casinos nearby", Allow, ""},
+		{"blocked pattern", "CRYPTO   giveaway", Reject, ReasonBlockedPattern},
+		{"reject beats quarantine", "www.a.com http://bit.ly", Reject, ReasonDomainDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Evaluate(tt.body)
+			if got.Action != tt.action || got.Reason != tt.reason {
+				t.Errorf("Evaluate(%q) = %+v, want action %d reason %q", tt.body, got, tt.action, tt.reason)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowedDomains(t *testing.T) {
+	p, err := Compile(Rules{
+		AllowedDomains: []string{"example.com"},
+		Actions:        map[string]string{ReasonDomainNotAllowed: "quarantine"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := p.Evaluate("https://shop.example.com/x"); v.Action != Allow {
+		t.Errorf("subdomain of allowed domain: %+v", v)
+	}
+	if v := p.Evaluate("https://other.org"); v.Action != Quarantine || v.Reason != ReasonDomainNotAllowed {
+		t.Errorf("other domain: %+v", v)
+	}
+}
+
+func TestCompileRejectsBadActions(t *testing.T) {
+	for _, actions := range []map[string]string{
+		{"TOO_MANY_LINK": "quarantine"},
+		{ReasonTooManyLinks: "hold"},
+	} {
+		if _, err := Compile(Rules{Actions: actions}); err == nil {
+			t.Errorf("Compile(actions %v) = nil error", actions)
+		}
+	}
+}
+
+func TestCompileRejectsBadPattern(t *testing.T) {
+	if _, err := Compile(Rules{BlockedPatterns: []string{"("}}); err == nil {
+		t.Error("Compile accepted an invalid pattern")
+	}
+}
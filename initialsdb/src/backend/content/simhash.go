@@ -0,0 +1,64 @@
+package content
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+/*
+SimHash fingerprint for near-duplicate detection.
+
+The body is normalized (lowercase, words of letters and digits joined
+by single spaces) and cut into overlapping 4-character shingles; each
+shingle's 64-bit FNV-1a hash votes on every bit. Small edits flip only
+a few bits, so two bodies are "near duplicates" when the Hamming
+distance of their fingerprints is small (in SQL:
+bit_count((a # b)::bit(64))). Unrelated texts sit around 32.
+
+Bodies with fewer than minSimHashWords words are not fingerprinted:
+"hi there" is not spam just because someone else wrote it too.
+*/
+
+const (
+	shingleRunes    = 4
+	minSimHashWords = 5
+)
+
+// SimHash returns the fingerprint as int64 (bit pattern of a uint64,
+// matches a BIGINT column), and false when the body is too short.
+func SimHash(body string) (int64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(body), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < minSimHashWords {
+		return 0, false
+	}
+
+	var votes [64]int
+
+	text := []rune(strings.Join(words, " "))
+
+	for i := 0; i+shingleRunes <= len(text); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(string(text[i : i+shingleRunes])))
+		sum := h.Sum64()
+
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+
+	var fp uint64
+	for bit := 0; bit < 64; bit++ {
+		if votes[bit] > 0 {
+			fp |= 1 << bit
+		}
+	}
+
+	return int64(fp), true
+}
@@ -0,0 +1,126 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Opaque keyset cursor over (created_at, id), shared by every
+// paginated endpoint that orders by created_at DESC, id DESC.
+//
+// Scored orders (relevance) prepend the float32 score, kept as its
+// bits so the SQL comparison against the recomputed score is exact.
+//
+// Paged cursors (listing search by date) append the direction they
+// were issued for: Next continues the requested order past the key,
+// Prev walks back before it.
+
+func Encode(t time.Time, id int64) string {
+	payload := strconv.FormatInt(t.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+func Decode(s string) (time.Time, int64, bool) {
+	parts, ok := split(s, 2)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	return parseKey(parts[0], parts[1])
+}
+
+type Dir byte
+
+const (
+	Next Dir = 'n'
+	Prev Dir = 'p'
+)
+
+func EncodePaged(t time.Time, id int64, dir Dir) string {
+	payload := strconv.FormatInt(t.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10) + ":" + string(dir)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+// DecodePaged also accepts a plain Encode cursor, as Next.
+func DecodePaged(s string) (time.Time, int64, Dir, bool) {
+	parts, ok := split(s, 3)
+	if !ok {
+		t, id, ok := Decode(s)
+		return t, id, Next, ok
+	}
+
+	dir := Dir(0)
+	switch parts[2] {
+	case string(Next):
+		dir = Next
+	case string(Prev):
+		dir = Prev
+	default:
+		return time.Time{}, 0, 0, false
+	}
+
+	t, id, ok := parseKey(parts[0], parts[1])
+	if !ok {
+		return time.Time{}, 0, 0, false
+	}
+	return t, id, dir, true
+}
+
+func EncodeScored(score float32, t time.Time, id int64) string {
+	payload := strconv.FormatUint(uint64(math.Float32bits(score)), 10) + ":" +
+		strconv.FormatInt(t.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+func DecodeScored(s string) (float32, time.Time, int64, bool) {
+	parts, ok := split(s, 3)
+	if !ok {
+		return 0, time.Time{}, 0, false
+	}
+
+	bits, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, time.Time{}, 0, false
+	}
+
+	score := math.Float32frombits(uint32(bits))
+	if math.IsNaN(float64(score)) || math.IsInf(float64(score), 0) {
+		return 0, time.Time{}, 0, false
+	}
+
+	t, id, ok := parseKey(parts[1], parts[2])
+	if !ok {
+		return 0, time.Time{}, 0, false
+	}
+
+	return score, t, id, true
+}
+
+func split(s string, n int) ([]string, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+
+	parts := strings.Split(string(b), ":")
+	if len(parts) != n {
+		return nil, false
+	}
+	return parts, true
+}
+
+func parseKey(nanos, id string) (time.Time, int64, bool) {
+	ns, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	return time.Unix(0, ns).UTC(), n, true
+}
@@ -0,0 +1,113 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// forge builds a token by hand so tests can pick the version and the
+// issued time.
+func forge(s *Signer, version byte, issued time.Time, cur, scope string) string {
+	b := make([]byte, headerLen)
+	b[0] = version
+	binary.BigEndian.PutUint64(b[1:9], uint64(issued.Unix()))
+	copy(b[9:17], scopeHash(scope))
+	b = append(b, cur...)
+	b = append(b, s.mac(b)...)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("k"), time.Hour)
+	cur := EncodePaged(time.Unix(1700000000, 123), 42, Next)
+
+	got, err := s.Open(s.Seal(cur, "q=bike"), "q=bike")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != cur {
+		t.Errorf("Open = %q, want %q", got, cur)
+	}
+
+	if s.Seal("", "q=bike") != "" {
+		t.Error("Seal of an empty cursor should be empty")
+	}
+}
+
+func TestSignerOpenErrors(t *testing.T) {
+	s := NewSigner([]byte("k"), time.Hour)
+	token := s.Seal("cursor", "q=bike")
+
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	raw[len(raw)-1] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(raw)
+
+	raw, _ = base64.RawURLEncoding.DecodeString(token)
+	raw[headerLen] ^= 1
+	tamperedBody := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name  string
+		s     *Signer
+		token string
+		scope string
+		want  error
+	}{
+		{"tampered mac", s, tampered, "q=bike", ErrInvalid},
+		{"tampered cursor", s, tamperedBody, "q=bike", ErrInvalid},
+		{"other key", NewSigner([]byte("other"), time.Hour), token, "q=bike", ErrInvalid},
+		{"not base64", s, "!!", "q=bike", ErrInvalid},
+		{"truncated", s, token[:10], "q=bike", ErrInvalid},
+		{"empty", s, "", "q=bike", ErrInvalid},
+		{"other scope", s, token, "q=car", ErrMismatch},
+		{"other version", s, forge(s, signedVersion+1, time.Now(), "cursor", "q=bike"), "q=bike", ErrInvalid},
+		{"random bytes", s, base64.RawURLEncoding.EncodeToString([]byte("\x07 not a cursor at all, just noise")), "q=bike", ErrInvalid},
+		{"unsigned date cursor", s, Encode(time.Now(), 42), "q=bike", ErrExpired},
+		{"unsigned paged cursor", s, EncodePaged(time.Now(), 42, Prev), "q=bike", ErrExpired},
+		{"unsigned scored cursor", s, EncodeScored(0.5, time.Now(), 42), "q=bike", ErrExpired},
+		{"elapsed ttl", s, forge(s, signedVersion, time.Now().Add(-2*time.Hour), "cursor", "q=bike"), "q=bike", ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.s.Open(tt.token, tt.scope); !errors.Is(err, tt.want) {
+				t.Errorf("Open = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerZeroTTL(t *testing.T) {
+	s := NewSigner([]byte("k"), 0)
+	token := forge(s, signedVersion, time.Now().Add(-24*time.Hour), "cursor", "q")
+
+	if _, err := s.Open(token, "q"); err != nil {
+		t.Errorf("ttl 0 should not expire: %v", err)
+	}
+}
+
+func TestNewSignerEmptyKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewSigner with an empty key did not panic")
+		}
+	}()
+	NewSigner(nil, time.Hour)
+}
+
+func TestDeriveKey(t *testing.T) {
+	if DeriveKey("") != nil {
+		t.Error("DeriveKey of an empty secret should be nil")
+	}
+
+	k := DeriveKey("salt")
+	if len(k) == 0 || string(k) == "salt" {
+		t.Errorf("DeriveKey(salt) = %x", k)
+	}
+	if string(DeriveKey("other")) == string(k) {
+		t.Error("different secrets gave the same key")
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Hand-written: sqlc cannot generate EXPLAIN. It reuses the generated
+// countSearchListings text, so the estimate is always for exactly the
+// same match. (That text opens with a "-- name:" line; the comment
+// ends at the newline, so prefixing EXPLAIN is fine.)
+
+// EstimateSearchListings returns the planner's row estimate for the
+// scan under the LIMIT of CountSearchListings, i.e. all matches,
+// without executing it. arg.RowCap only has to be positive.
+func (q *Queries) EstimateSearchListings(ctx context.Context, arg CountSearchListingsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+countSearchListings,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.Q,
+		arg.Web,
+		arg.Lang,
+		arg.RowCap,
+	)
+
+	var raw []byte
+	if err := row.Scan(&raw); err != nil {
+		return 0, err
+	}
+
+	var explain []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &explain); err != nil {
+		return 0, err
+	}
+	if len(explain) == 0 {
+		return 0, errors.New("explain: empty plan")
+	}
+
+	n, ok := explain[0].Plan.belowLimit()
+	if !ok {
+		return 0, errors.New("explain: no limit node")
+	}
+	return int64(n), nil
+}
+
+type planNode struct {
+	NodeType string     `json:"Node Type"`
+	PlanRows float64    `json:"Plan Rows"`
+	Plans    []planNode `json:"Plans"`
+}
+
+// belowLimit finds the first Limit node and returns its input's rows.
+func (p planNode) belowLimit() (float64, bool) {
+	if p.NodeType == "Limit" && len(p.Plans) > 0 {
+		return p.Plans[0].PlanRows, true
+	}
+
+	for _, c := range p.Plans {
+		if n, ok := c.belowLimit(); ok {
+			return n, true
+		}
+	}
+	return 0, false
+}
@@ -12,8 +12,17 @@ import (
 	"time"
 )
 
+const (
+	setupTimeout = 30 * time.Second
+
+	// Per migration, not shared: a table rewrite with a backfill and
+	// an index rebuild (012) takes minutes on a large table, and the
+	// server only starts after it.
+	migrationTimeout = 30 * time.Minute
+)
+
 func RunMigrations(db *sql.DB) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
 	defer cancel()
 
 	// Ensure migrations table exists
@@ -33,7 +42,10 @@ func RunMigrations(db *sql.DB) error {
 	}
 
 	for _, path := range files {
-		if err := applyOne(ctx, db, path); err != nil {
+		mctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+		err := applyOne(mctx, db, path)
+		cancel()
+		if err != nil {
 			return err
 		}
 	}
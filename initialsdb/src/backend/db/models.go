@@ -9,15 +9,54 @@ import (
 	"time"
 )
 
+type Ban struct {
+	IpHash    []byte
+	Reason    string
+	Actor     string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Shadow    bool
+}
+
 type Listing struct {
-	ID         int64
-	Body       string
-	IsHidden   bool
-	HiddenAt   sql.NullTime
-	CreatedAt  time.Time
-	IpHash     []byte
-	BodyLength sql.NullInt32
-	HasLinks   sql.NullBool
-	LinkCount  sql.NullInt32
-	BodyTsv    interface{}
+	ID              int64
+	Body            string
+	IsHidden        bool
+	HiddenAt        sql.NullTime
+	CreatedAt       time.Time
+	IpHash          []byte
+	BodyLength      sql.NullInt32
+	HasLinks        sql.NullBool
+	LinkCount       sql.NullInt32
+	Simhash         sql.NullInt64
+	IsPending       bool
+	IsShadowed      bool
+	DeleteTokenHash []byte
+	EditedAt        sql.NullTime
+	ExpiresAt       sql.NullTime
+	Lang            interface{}
+	BodyTsv         interface{}
+}
+
+type ListingRevision struct {
+	ID        int64
+	ListingID int64
+	Body      string
+	CreatedAt time.Time
+}
+
+type ListingTerm struct {
+	Word   string
+	Ndoc   int32
+	Nentry int32
+}
+
+type ModerationEvent struct {
+	ID        int64
+	ListingID sql.NullInt64
+	IpHash    []byte
+	Actor     string
+	Action    string
+	Reason    string
+	CreatedAt time.Time
 }
@@ -5,56 +5,494 @@
 -- name: CreateListing :one
 INSERT INTO listings (
     body,
-    ip_hash
+    ip_hash,
+    is_hidden,
+    hidden_at,
+    simhash,
+    is_pending,
+    is_shadowed,
+    delete_token_hash,
+    expires_at,
+    lang
 ) VALUES (
     $1,
-    $2
+    $2,
+    $3,
+    CASE WHEN $3::boolean THEN now() END,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9::text::regconfig
 )
 RETURNING
     id,
     body,
     is_hidden,
-    created_at;
+    is_pending,
+    created_at,
+    expires_at;
+
+
+-- Author retraction: hides the listing and burns the token.
+-- Shadowed rows are accepted too, so a shadow ban does not leak;
+-- clearing is_shadowed keeps them out of the author's own view.
+
+-- name: RetractListing :execrows
+UPDATE listings
+SET
+    is_hidden = TRUE,
+    hidden_at = COALESCE(hidden_at, now()),
+    is_pending = FALSE,
+    is_shadowed = FALSE,
+    delete_token_hash = NULL
+WHERE
+    id = $1
+    AND delete_token_hash = $2
+    AND (is_hidden = FALSE OR is_shadowed = TRUE);
+
+
+-- Public single-listing read. Shadowed rows only for their source.
+
+-- name: GetVisibleListing :one
+SELECT
+    id,
+    body,
+    created_at,
+    edited_at,
+    expires_at
+FROM listings
+WHERE
+    id = sqlc.arg(id)
+    AND is_pending = FALSE
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    );
+
+
+-- =====================================================
+-- LISTING EDITS (AUTHOR TOKEN, TIME WINDOW)
+-- =====================================================
+
+-- Locks the row for the edit transaction. No row = unknown id,
+-- wrong token, retracted, or window closed.
+
+-- name: GetEditableListing :one
+SELECT
+    id,
+    body,
+    is_shadowed
+FROM listings
+WHERE
+    id = sqlc.arg(id)
+    AND delete_token_hash = sqlc.arg(delete_token_hash)
+    AND (is_hidden = FALSE OR is_shadowed = TRUE)
+    AND created_at >= sqlc.arg(created_after)
+FOR UPDATE;
+
+
+-- name: UpdateListingBody :one
+UPDATE listings
+SET
+    body = sqlc.arg(body),
+    simhash = sqlc.arg(simhash),
+    is_pending = is_pending OR sqlc.arg(hold)::boolean,
+    is_hidden = is_hidden OR sqlc.arg(hide)::boolean,
+    hidden_at = CASE WHEN sqlc.arg(hide)::boolean THEN COALESCE(hidden_at, now()) ELSE hidden_at END,
+    edited_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING
+    id,
+    body,
+    is_hidden,
+    is_pending,
+    created_at,
+    edited_at;
+
+
+-- name: CreateListingRevision :exec
+INSERT INTO listing_revisions (
+    listing_id,
+    body
+) VALUES (
+    $1,
+    $2
+);
+
+
+-- name: CountListingRevisions :one
+SELECT count(*)
+FROM listing_revisions
+WHERE listing_id = $1;
+
+
+-- name: ListListingRevisions :many
+SELECT
+    id,
+    body,
+    created_at
+FROM listing_revisions
+WHERE listing_id = $1
+ORDER BY id DESC
+LIMIT $2;
 
 
 -- =====================================================
 -- LISTINGS SEARCH (KEYSET PAGINATION)
 -- =====================================================
 
+-- Every search below takes the same optional filters (NULL = off):
+-- created_at in [since, until), has_links, body_length in
+-- [min_length, max_length], link_count <= max_links. They only narrow
+-- the rows; the keyset condition is unchanged.
+
+-- Visibility is the same as GetVisibleListing: public rows, plus the
+-- shadowed rows of viewer_ip_hash, a shadow-banned source searching
+-- (NULL for everyone else). Its rows sort and page like any other,
+-- so each order needs no second query.
+
+-- snippet: ts_headline of the match when headline_options is not
+-- empty (highlight=1), NULL otherwise; built only for returned rows.
+-- \x01/\x02 are the match markers, so they are stripped from the body.
+
 -- name: SearchListingsFirstPage :many
 SELECT
     id,
     body,
-    created_at
+    created_at,
+    expires_at,
+    CASE
+        WHEN sqlc.arg(headline_options)::text <> '' THEN ts_headline(
+            sqlc.arg(lang)::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)),
+            sqlc.arg(headline_options)
+        )
+    END AS snippet
 FROM listings
 WHERE
-    is_hidden = FALSE
+    is_pending = FALSE
     AND (
-        $1::text IS NULL
-        OR body_tsv @@ plainto_tsquery('simple', $1)
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND (
+        sqlc.arg(q)::text IS NULL
+        OR body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
     )
 ORDER BY created_at DESC, id DESC
-LIMIT $2;
+LIMIT sqlc.arg(row_limit);
 
 
 -- name: SearchListingsAfterCursor :many
 SELECT
     id,
     body,
-    created_at
+    created_at,
+    expires_at,
+    CASE
+        WHEN sqlc.arg(headline_options)::text <> '' THEN ts_headline(
+            sqlc.arg(lang)::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)),
+            sqlc.arg(headline_options)
+        )
+    END AS snippet
 FROM listings
 WHERE
-    is_hidden = FALSE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
     AND (
-        $1::text IS NULL
-        OR body_tsv @@ plainto_tsquery('simple', $1)
+        sqlc.arg(q)::text IS NULL
+        OR body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
     )
     AND (
-        created_at < $2
-        OR (created_at = $2 AND id < $3)
+        created_at < sqlc.arg(created_at)
+        OR (created_at = sqlc.arg(created_at) AND id < sqlc.arg(id))
     )
 ORDER BY created_at DESC, id DESC
-LIMIT $4;
+LIMIT sqlc.arg(row_limit);
+
+
+-- Oldest first (order=asc), and the scan behind prev_cursor for the
+-- newest-first order. The (created_at DESC, id DESC) indexes are
+-- read backwards.
+
+-- name: SearchListingsAscFirstPage :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    CASE
+        WHEN sqlc.arg(headline_options)::text <> '' THEN ts_headline(
+            sqlc.arg(lang)::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)),
+            sqlc.arg(headline_options)
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND (
+        sqlc.arg(q)::text IS NULL
+        OR body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: SearchListingsAscAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    CASE
+        WHEN sqlc.arg(headline_options)::text <> '' THEN ts_headline(
+            sqlc.arg(lang)::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)),
+            sqlc.arg(headline_options)
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND (
+        sqlc.arg(q)::text IS NULL
+        OR body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
+    )
+    AND (
+        created_at > sqlc.arg(created_at)
+        OR (created_at = sqlc.arg(created_at) AND id > sqlc.arg(id))
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(row_limit);
+
+
+-- Relevance order: ts_rank_cd over the same match, ties broken by
+-- (created_at, id) so the keyset stays total.
+
+-- name: SearchListingsByRankFirstPage :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    ts_rank_cd(body_tsv, listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)))::real AS rank,
+    CASE
+        WHEN sqlc.arg(headline_options)::text <> '' THEN ts_headline(
+            sqlc.arg(lang)::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)),
+            sqlc.arg(headline_options)
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: SearchListingsByRankAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    ts_rank_cd(body_tsv, listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)))::real AS rank,
+    CASE
+        WHEN sqlc.arg(headline_options)::text <> '' THEN ts_headline(
+            sqlc.arg(lang)::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)),
+            sqlc.arg(headline_options)
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
+    AND (
+        ts_rank_cd(body_tsv, listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)))::real < sqlc.arg(rank)::real
+        OR (
+            ts_rank_cd(body_tsv, listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang)))::real = sqlc.arg(rank)::real
+            AND (
+                created_at < sqlc.arg(created_at)
+                OR (created_at = sqlc.arg(created_at) AND id < sqlc.arg(id))
+            )
+        )
+    )
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- Fuzzy order (mode=fuzzy): trigram word similarity for typos and
+-- partial words, ILIKE for exact substrings such as phone numbers.
+-- pattern is q with LIKE wildcards escaped, wrapped in %...%.
+
+-- name: SearchListingsFuzzyFirstPage :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    word_similarity(sqlc.arg(q), body)::real AS score
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND (
+        sqlc.arg(q)::text <% body
+        OR body ILIKE sqlc.arg(pattern)::text
+    )
+ORDER BY score DESC, created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: SearchListingsFuzzyAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    word_similarity(sqlc.arg(q), body)::real AS score
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+    AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+    AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+    AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+    AND (
+        sqlc.arg(q)::text <% body
+        OR body ILIKE sqlc.arg(pattern)::text
+    )
+    AND (
+        word_similarity(sqlc.arg(q), body)::real < sqlc.arg(score)::real
+        OR (
+            word_similarity(sqlc.arg(q), body)::real = sqlc.arg(score)::real
+            AND (
+                created_at < sqlc.arg(created_at)
+                OR (created_at = sqlc.arg(created_at) AND id < sqlc.arg(id))
+            )
+        )
+    )
+ORDER BY score DESC, created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- with_total: visible matches for the same filter, counted up to
+-- row_cap. Past that the handler falls back to the planner's estimate
+-- of the scan under the LIMIT node (EXPLAIN of this very query, see
+-- db/estimate.go).
+
+-- name: CountSearchListings :one
+SELECT COUNT(*)::bigint
+FROM (
+    SELECT 1
+    FROM listings
+    WHERE
+        is_pending = FALSE
+        AND (
+            is_hidden = FALSE
+            OR (is_shadowed = TRUE AND ip_hash = sqlc.narg(viewer_ip_hash))
+        )
+        AND (expires_at IS NULL OR expires_at > now())
+        AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+        AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+        AND (sqlc.narg(has_links)::boolean IS NULL OR has_links = sqlc.narg(has_links))
+        AND (sqlc.narg(min_length)::integer IS NULL OR body_length >= sqlc.narg(min_length))
+        AND (sqlc.narg(max_length)::integer IS NULL OR body_length <= sqlc.narg(max_length))
+        AND (sqlc.narg(max_links)::integer IS NULL OR link_count <= sqlc.narg(max_links))
+        AND body_tsv @@ listing_tsquery(sqlc.arg(q), sqlc.arg(web), sqlc.arg(lang))
+    LIMIT sqlc.arg(row_cap)
+) AS hits;
 
 
 -- =====================================================
@@ -62,11 +500,23 @@ LIMIT $4;
 -- =====================================================
 
 -- name: CountRecentListingsByIP :one
-SELECT COUNT(*)
+SELECT
+    COUNT(*) FILTER (
+        WHERE created_at >= now() - INTERVAL '1 hour'
+    ) AS last_hour,
+    COUNT(*) AS last_day
 FROM listings
 WHERE
     ip_hash = $1
-    AND created_at >= now() - INTERVAL '1 hour';
+    AND created_at >= now() - INTERVAL '1 day';
+
+
+-- name: ListingExistsByIP :one
+SELECT EXISTS (
+    SELECT 1
+    FROM listings
+    WHERE ip_hash = $1
+);
 
 
 -- name: TouchListingsByIP :exec
@@ -82,4 +532,451 @@ WHERE ip_hash = $1;
 -- name: CountVisibleListings :one
 SELECT COUNT(*)::bigint
 FROM listings
-WHERE is_hidden = FALSE;
+WHERE
+    is_hidden = FALSE
+    AND is_pending = FALSE
+    AND (expires_at IS NULL OR expires_at > now());
+
+
+-- Operator overview (server stats subcommand)
+
+-- name: ListingStats :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (
+        WHERE is_hidden = FALSE
+            AND is_pending = FALSE
+            AND (expires_at IS NULL OR expires_at > now())
+    ) AS visible,
+    COUNT(*) FILTER (WHERE is_hidden = TRUE) AS hidden,
+    COUNT(*) FILTER (WHERE is_pending = TRUE) AS pending,
+    COUNT(*) FILTER (
+        WHERE created_at >= now() - INTERVAL '1 day'
+    ) AS last_day
+FROM listings;
+
+
+-- =====================================================
+-- MODERATION (ADMIN)
+-- =====================================================
+
+-- Clearing is_shadowed hides a shadowed row from its source too.
+
+-- name: HideListing :one
+UPDATE listings
+SET
+    is_hidden = TRUE,
+    hidden_at = COALESCE(hidden_at, now()),
+    is_pending = FALSE,
+    is_shadowed = FALSE
+WHERE id = $1
+RETURNING
+    id,
+    is_hidden,
+    hidden_at;
+
+
+-- name: UnhideListing :one
+UPDATE listings
+SET
+    is_hidden = FALSE,
+    hidden_at = NULL,
+    is_shadowed = FALSE
+WHERE id = $1
+RETURNING
+    id,
+    is_hidden,
+    hidden_at;
+
+
+-- name: AdminSearchListingsFirstPage :many
+SELECT
+    id,
+    body,
+    is_hidden,
+    hidden_at,
+    is_pending,
+    created_at,
+    ip_hash
+FROM listings
+WHERE
+    (
+        sqlc.arg(include_hidden)::boolean
+        OR (is_hidden = FALSE AND is_pending = FALSE)
+    )
+    AND (
+        sqlc.arg(q)::text = ''
+        OR body_tsv @@ plainto_tsquery('simple', sqlc.arg(q))
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: AdminSearchListingsAfterCursor :many
+SELECT
+    id,
+    body,
+    is_hidden,
+    hidden_at,
+    is_pending,
+    created_at,
+    ip_hash
+FROM listings
+WHERE
+    (
+        sqlc.arg(include_hidden)::boolean
+        OR (is_hidden = FALSE AND is_pending = FALSE)
+    )
+    AND (
+        sqlc.arg(q)::text = ''
+        OR body_tsv @@ plainto_tsquery('simple', sqlc.arg(q))
+    )
+    AND (
+        created_at < sqlc.arg(created_at)
+        OR (created_at = sqlc.arg(created_at) AND id < sqlc.arg(id))
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- =====================================================
+-- MODERATION AUDIT LOG
+-- =====================================================
+
+-- name: CreateModerationEvent :exec
+INSERT INTO moderation_events (
+    listing_id,
+    ip_hash,
+    actor,
+    action,
+    reason
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+
+-- name: ListModerationEventsFirstPage :many
+SELECT
+    id,
+    listing_id,
+    ip_hash,
+    actor,
+    action,
+    reason,
+    created_at
+FROM moderation_events
+WHERE
+    sqlc.narg(listing_id)::bigint IS NULL
+    OR listing_id = sqlc.narg(listing_id)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: ListModerationEventsAfterCursor :many
+SELECT
+    id,
+    listing_id,
+    ip_hash,
+    actor,
+    action,
+    reason,
+    created_at
+FROM moderation_events
+WHERE
+    (
+        sqlc.narg(listing_id)::bigint IS NULL
+        OR listing_id = sqlc.narg(listing_id)
+    )
+    AND (
+        created_at < sqlc.arg(created_at)
+        OR (created_at = sqlc.arg(created_at) AND id < sqlc.arg(id))
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+
+-- =====================================================
+-- BANS
+-- =====================================================
+
+-- name: UpsertBan :one
+INSERT INTO bans (
+    ip_hash,
+    reason,
+    actor,
+    expires_at,
+    shadow
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (ip_hash) DO UPDATE
+SET
+    reason = EXCLUDED.reason,
+    actor = EXCLUDED.actor,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at,
+    shadow = EXCLUDED.shadow
+RETURNING
+    ip_hash,
+    reason,
+    actor,
+    created_at,
+    expires_at,
+    shadow;
+
+
+-- name: CountActiveBans :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE shadow) AS shadow
+FROM bans
+WHERE expires_at IS NULL OR expires_at > now();
+
+
+-- name: DeleteBan :execrows
+DELETE FROM bans
+WHERE ip_hash = $1;
+
+
+-- name: ListActiveBans :many
+SELECT
+    ip_hash,
+    reason,
+    actor,
+    created_at,
+    expires_at,
+    shadow
+FROM bans
+WHERE
+    expires_at IS NULL
+    OR expires_at > now()
+ORDER BY created_at DESC;
+
+
+-- =====================================================
+-- BULK MODERATION BY ip_hash
+-- =====================================================
+-- Both use idx_listings_ip_hash_created_at; the time range is optional.
+-- Shadowed rows count as visible: their source still sees them.
+
+-- name: ListListingIDsByIP :many
+SELECT id
+FROM listings
+WHERE
+    ip_hash = sqlc.arg(ip_hash)
+    AND (is_hidden = FALSE OR is_shadowed = TRUE)
+    AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC, id DESC;
+
+
+-- Hides and writes one audit row per listing in a single statement,
+-- so a flood of thousands of rows is still one round trip.
+
+-- name: HideListingsByIP :many
+WITH hidden AS (
+    UPDATE listings
+    SET
+        is_hidden = TRUE,
+        hidden_at = COALESCE(hidden_at, now()),
+        is_pending = FALSE,
+        is_shadowed = FALSE
+    WHERE
+        ip_hash = sqlc.arg(ip_hash)
+        AND (is_hidden = FALSE OR is_shadowed = TRUE)
+        AND (sqlc.narg(since)::timestamptz IS NULL OR created_at >= sqlc.narg(since))
+        AND (sqlc.narg(until)::timestamptz IS NULL OR created_at < sqlc.narg(until))
+    RETURNING id
+), logged AS (
+    INSERT INTO moderation_events (listing_id, ip_hash, actor, action, reason)
+    SELECT id, sqlc.arg(ip_hash), sqlc.arg(actor), sqlc.arg(action), sqlc.arg(reason)
+    FROM hidden
+)
+SELECT id
+FROM hidden;
+
+
+-- =====================================================
+-- NEAR-DUPLICATES (SIMHASH)
+-- =====================================================
+-- Hidden rows count too: a hidden spam must still block its reposts.
+-- exclude_id skips the listing being edited (0 on create).
+
+-- name: FindNearDuplicateListing :one
+SELECT id
+FROM listings
+WHERE
+    simhash IS NOT NULL
+    AND created_at >= sqlc.arg(since)
+    AND bit_count((simhash # sqlc.arg(simhash)::bigint)::bit(64)) <= sqlc.arg(max_distance)::integer
+    AND id <> sqlc.arg(exclude_id)
+ORDER BY created_at DESC
+LIMIT 1;
+
+
+-- =====================================================
+-- PRE-MODERATION QUEUE (OLDEST FIRST)
+-- =====================================================
+
+-- name: ListPendingFirstPage :many
+SELECT
+    id,
+    body,
+    created_at,
+    ip_hash,
+    link_count
+FROM listings
+WHERE is_pending = TRUE
+ORDER BY created_at, id
+LIMIT $1;
+
+
+-- name: ListPendingAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    ip_hash,
+    link_count
+FROM listings
+WHERE
+    is_pending = TRUE
+    AND (
+        created_at > $1
+        OR (created_at = $1 AND id > $2)
+    )
+ORDER BY created_at, id
+LIMIT $3;
+
+
+-- name: ApprovePendingListing :execrows
+UPDATE listings
+SET is_pending = FALSE
+WHERE
+    id = $1
+    AND is_pending = TRUE;
+
+
+-- name: RejectPendingListing :execrows
+UPDATE listings
+SET
+    is_pending = FALSE,
+    is_hidden = TRUE,
+    hidden_at = now()
+WHERE
+    id = $1
+    AND is_pending = TRUE;
+
+
+-- =====================================================
+-- RETENTION (BATCHED)
+-- =====================================================
+
+-- An empty actor skips the audit rows (the scheduled job); the CLI
+-- logs one row per purged listing, like any other change.
+
+-- name: PurgeHiddenListings :one
+WITH purged AS (
+    DELETE FROM listings
+    WHERE id IN (
+        SELECT id
+        FROM listings
+        WHERE
+            is_hidden = TRUE
+            AND hidden_at < sqlc.arg(hidden_before)
+        ORDER BY hidden_at
+        LIMIT sqlc.arg(batch_size)
+    )
+    RETURNING id, ip_hash
+), logged AS (
+    INSERT INTO moderation_events (listing_id, ip_hash, actor, action, reason)
+    SELECT id, ip_hash, sqlc.arg(actor), sqlc.arg(action), sqlc.arg(reason)
+    FROM purged
+    WHERE sqlc.arg(actor)::text <> ''
+)
+SELECT count(*)
+FROM purged;
+
+
+-- name: AnonymizeListingIPs :execrows
+UPDATE listings
+SET ip_hash = NULL
+WHERE id IN (
+    SELECT id
+    FROM listings
+    WHERE
+        ip_hash IS NOT NULL
+        AND created_at < sqlc.arg(created_before)
+    ORDER BY created_at
+    LIMIT sqlc.arg(batch_size)
+);
+
+
+-- =====================================================
+-- EXPIRY (BATCHED)
+-- =====================================================
+
+-- name: HideExpiredListings :execrows
+UPDATE listings
+SET
+    is_hidden = TRUE,
+    hidden_at = expires_at,
+    is_pending = FALSE
+WHERE id IN (
+    SELECT id
+    FROM listings
+    WHERE
+        expires_at IS NOT NULL
+        AND is_hidden = FALSE
+        AND expires_at <= now()
+    ORDER BY expires_at
+    LIMIT sqlc.arg(batch_size)
+);
+
+
+-- =====================================================
+-- SEARCH SUGGESTIONS (TERM STATISTICS)
+-- =====================================================
+-- Rebuilt in one transaction: delete, then insert from ts_stat.
+
+-- name: DeleteListingTerms :exec
+DELETE FROM listing_terms;
+
+
+-- ts_stat over the stored body_tsv, so nothing is parsed again and
+-- every suggestion is a lexeme search indexes: the exact words and,
+-- since 012, the language stems ("hous"). Words in fewer than
+-- min_docs listings are left out, so one-off names and numbers are
+-- never suggested.
+
+-- name: InsertListingTerms :execrows
+INSERT INTO listing_terms (word, ndoc, nentry)
+SELECT word, ndoc, nentry
+FROM ts_stat(
+    'SELECT body_tsv
+     FROM listings
+     WHERE is_hidden = FALSE
+       AND is_pending = FALSE
+       AND (expires_at IS NULL OR expires_at > now())'
+)
+WHERE ndoc >= sqlc.arg(min_docs)::integer;
+
+
+-- pattern is the prefix with LIKE wildcards escaped, plus '%'.
+
+-- name: SuggestListingTerms :many
+SELECT
+    word,
+    ndoc
+FROM listing_terms
+WHERE word LIKE sqlc.arg(pattern)::text
+ORDER BY ndoc DESC, word
+LIMIT sqlc.arg(row_limit);
@@ -7,136 +7,2068 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const adminSearchListingsAfterCursor = `-- name: AdminSearchListingsAfterCursor :many
+SELECT
+    id,
+    body,
+    is_hidden,
+    hidden_at,
+    is_pending,
+    created_at,
+    ip_hash
+FROM listings
+WHERE
+    (
+        $1::boolean
+        OR (is_hidden = FALSE AND is_pending = FALSE)
+    )
+    AND (
+        $2::text = ''
+        OR body_tsv @@ plainto_tsquery('simple', $2)
+    )
+    AND (
+        created_at < $3
+        OR (created_at = $3 AND id < $4)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type AdminSearchListingsAfterCursorParams struct {
+	IncludeHidden bool
+	Q             string
+	CreatedAt     time.Time
+	ID            int64
+	RowLimit      int32
+}
+
+type AdminSearchListingsAfterCursorRow struct {
+	ID        int64
+	Body      string
+	IsHidden  bool
+	HiddenAt  sql.NullTime
+	IsPending bool
+	CreatedAt time.Time
+	IpHash    []byte
+}
+
+func (q *Queries) AdminSearchListingsAfterCursor(ctx context.Context, arg AdminSearchListingsAfterCursorParams) ([]AdminSearchListingsAfterCursorRow, error) {
+	rows, err := q.db.QueryContext(ctx, adminSearchListingsAfterCursor,
+		arg.IncludeHidden,
+		arg.Q,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AdminSearchListingsAfterCursorRow{}
+	for rows.Next() {
+		var i AdminSearchListingsAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.IsHidden,
+			&i.HiddenAt,
+			&i.IsPending,
+			&i.CreatedAt,
+			&i.IpHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const adminSearchListingsFirstPage = `-- name: AdminSearchListingsFirstPage :many
+SELECT
+    id,
+    body,
+    is_hidden,
+    hidden_at,
+    is_pending,
+    created_at,
+    ip_hash
+FROM listings
+WHERE
+    (
+        $1::boolean
+        OR (is_hidden = FALSE AND is_pending = FALSE)
+    )
+    AND (
+        $2::text = ''
+        OR body_tsv @@ plainto_tsquery('simple', $2)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type AdminSearchListingsFirstPageParams struct {
+	IncludeHidden bool
+	Q             string
+	RowLimit      int32
+}
+
+type AdminSearchListingsFirstPageRow struct {
+	ID        int64
+	Body      string
+	IsHidden  bool
+	HiddenAt  sql.NullTime
+	IsPending bool
+	CreatedAt time.Time
+	IpHash    []byte
+}
+
+func (q *Queries) AdminSearchListingsFirstPage(ctx context.Context, arg AdminSearchListingsFirstPageParams) ([]AdminSearchListingsFirstPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, adminSearchListingsFirstPage, arg.IncludeHidden, arg.Q, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AdminSearchListingsFirstPageRow{}
+	for rows.Next() {
+		var i AdminSearchListingsFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.IsHidden,
+			&i.HiddenAt,
+			&i.IsPending,
+			&i.CreatedAt,
+			&i.IpHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const anonymizeListingIPs = `-- name: AnonymizeListingIPs :execrows
+UPDATE listings
+SET ip_hash = NULL
+WHERE id IN (
+    SELECT id
+    FROM listings
+    WHERE
+        ip_hash IS NOT NULL
+        AND created_at < $1
+    ORDER BY created_at
+    LIMIT $2
+)
+`
+
+type AnonymizeListingIPsParams struct {
+	CreatedBefore time.Time
+	BatchSize     int32
+}
+
+func (q *Queries) AnonymizeListingIPs(ctx context.Context, arg AnonymizeListingIPsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, anonymizeListingIPs, arg.CreatedBefore, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const approvePendingListing = `-- name: ApprovePendingListing :execrows
+UPDATE listings
+SET is_pending = FALSE
+WHERE
+    id = $1
+    AND is_pending = TRUE
+`
+
+func (q *Queries) ApprovePendingListing(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, approvePendingListing, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countActiveBans = `-- name: CountActiveBans :one
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (WHERE shadow) AS shadow
+FROM bans
+WHERE expires_at IS NULL OR expires_at > now()
+`
+
+type CountActiveBansRow struct {
+	Total  int64
+	Shadow int64
+}
+
+func (q *Queries) CountActiveBans(ctx context.Context) (CountActiveBansRow, error) {
+	row := q.db.QueryRowContext(ctx, countActiveBans)
+	var i CountActiveBansRow
+	err := row.Scan(&i.Total, &i.Shadow)
+	return i, err
+}
+
+const countListingRevisions = `-- name: CountListingRevisions :one
+SELECT count(*)
+FROM listing_revisions
+WHERE listing_id = $1
+`
+
+func (q *Queries) CountListingRevisions(ctx context.Context, listingID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countListingRevisions, listingID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countRecentListingsByIP = `-- name: CountRecentListingsByIP :one
 
-SELECT COUNT(*)
+SELECT
+    COUNT(*) FILTER (
+        WHERE created_at >= now() - INTERVAL '1 hour'
+    ) AS last_hour,
+    COUNT(*) AS last_day
+FROM listings
+WHERE
+    ip_hash = $1
+    AND created_at >= now() - INTERVAL '1 day'
+`
+
+type CountRecentListingsByIPRow struct {
+	LastHour int64
+	LastDay  int64
+}
+
+// =====================================================
+// BOT / RATE LIMITING HELPERS
+// =====================================================
+func (q *Queries) CountRecentListingsByIP(ctx context.Context, ipHash []byte) (CountRecentListingsByIPRow, error) {
+	row := q.db.QueryRowContext(ctx, countRecentListingsByIP, ipHash)
+	var i CountRecentListingsByIPRow
+	err := row.Scan(&i.LastHour, &i.LastDay)
+	return i, err
+}
+
+const countSearchListings = `-- name: CountSearchListings :one
+
+SELECT COUNT(*)::bigint
+FROM (
+    SELECT 1
+    FROM listings
+    WHERE
+        is_pending = FALSE
+        AND (
+            is_hidden = FALSE
+            OR (is_shadowed = TRUE AND ip_hash = $1)
+        )
+        AND (expires_at IS NULL OR expires_at > now())
+        AND ($2::timestamptz IS NULL OR created_at >= $2)
+        AND ($3::timestamptz IS NULL OR created_at < $3)
+        AND ($4::boolean IS NULL OR has_links = $4)
+        AND ($5::integer IS NULL OR body_length >= $5)
+        AND ($6::integer IS NULL OR body_length <= $6)
+        AND ($7::integer IS NULL OR link_count <= $7)
+        AND body_tsv @@ listing_tsquery($8, $9, $10)
+    LIMIT $11
+) AS hits
+`
+
+type CountSearchListingsParams struct {
+	ViewerIpHash []byte
+	Since        sql.NullTime
+	Until        sql.NullTime
+	HasLinks     sql.NullBool
+	MinLength    sql.NullInt32
+	MaxLength    sql.NullInt32
+	MaxLinks     sql.NullInt32
+	Q            string
+	Web          bool
+	Lang         string
+	RowCap       int32
+}
+
+// with_total: visible matches for the same filter, counted up to
+// row_cap. Past that the handler falls back to the planner's estimate
+// of the scan under the LIMIT node (EXPLAIN of this very query, see
+// db/estimate.go).
+func (q *Queries) CountSearchListings(ctx context.Context, arg CountSearchListingsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchListings,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.Q,
+		arg.Web,
+		arg.Lang,
+		arg.RowCap,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countVisibleListings = `-- name: CountVisibleListings :one
+
+SELECT COUNT(*)::bigint
+FROM listings
+WHERE
+    is_hidden = FALSE
+    AND is_pending = FALSE
+    AND (expires_at IS NULL OR expires_at > now())
+`
+
+// =====================================================
+// Global counter for DB entries above search
+// =====================================================
+func (q *Queries) CountVisibleListings(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countVisibleListings)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createListing = `-- name: CreateListing :one
+
+INSERT INTO listings (
+    body,
+    ip_hash,
+    is_hidden,
+    hidden_at,
+    simhash,
+    is_pending,
+    is_shadowed,
+    delete_token_hash,
+    expires_at,
+    lang
+) VALUES (
+    $1,
+    $2,
+    $3,
+    CASE WHEN $3::boolean THEN now() END,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9::text::regconfig
+)
+RETURNING
+    id,
+    body,
+    is_hidden,
+    is_pending,
+    created_at,
+    expires_at
+`
+
+type CreateListingParams struct {
+	Body            string
+	IpHash          []byte
+	IsHidden        bool
+	Simhash         sql.NullInt64
+	IsPending       bool
+	IsShadowed      bool
+	DeleteTokenHash []byte
+	ExpiresAt       sql.NullTime
+	Lang            string
+}
+
+type CreateListingRow struct {
+	ID        int64
+	Body      string
+	IsHidden  bool
+	IsPending bool
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+// =====================================================
+// LISTINGS
+// =====================================================
+func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (CreateListingRow, error) {
+	row := q.db.QueryRowContext(ctx, createListing,
+		arg.Body,
+		arg.IpHash,
+		arg.IsHidden,
+		arg.Simhash,
+		arg.IsPending,
+		arg.IsShadowed,
+		arg.DeleteTokenHash,
+		arg.ExpiresAt,
+		arg.Lang,
+	)
+	var i CreateListingRow
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.IsHidden,
+		&i.IsPending,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createListingRevision = `-- name: CreateListingRevision :exec
+INSERT INTO listing_revisions (
+    listing_id,
+    body
+) VALUES (
+    $1,
+    $2
+)
+`
+
+type CreateListingRevisionParams struct {
+	ListingID int64
+	Body      string
+}
+
+func (q *Queries) CreateListingRevision(ctx context.Context, arg CreateListingRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createListingRevision, arg.ListingID, arg.Body)
+	return err
+}
+
+const createModerationEvent = `-- name: CreateModerationEvent :exec
+
+INSERT INTO moderation_events (
+    listing_id,
+    ip_hash,
+    actor,
+    action,
+    reason
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+`
+
+type CreateModerationEventParams struct {
+	ListingID sql.NullInt64
+	IpHash    []byte
+	Actor     string
+	Action    string
+	Reason    string
+}
+
+// =====================================================
+// MODERATION AUDIT LOG
+// =====================================================
+func (q *Queries) CreateModerationEvent(ctx context.Context, arg CreateModerationEventParams) error {
+	_, err := q.db.ExecContext(ctx, createModerationEvent,
+		arg.ListingID,
+		arg.IpHash,
+		arg.Actor,
+		arg.Action,
+		arg.Reason,
+	)
+	return err
+}
+
+const deleteBan = `-- name: DeleteBan :execrows
+DELETE FROM bans
+WHERE ip_hash = $1
+`
+
+func (q *Queries) DeleteBan(ctx context.Context, ipHash []byte) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteBan, ipHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteListingTerms = `-- name: DeleteListingTerms :exec
+
+DELETE FROM listing_terms
+`
+
+// =====================================================
+// SEARCH SUGGESTIONS (TERM STATISTICS)
+// =====================================================
+// Rebuilt in one transaction: delete, then insert from ts_stat.
+func (q *Queries) DeleteListingTerms(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteListingTerms)
+	return err
+}
+
+const findNearDuplicateListing = `-- name: FindNearDuplicateListing :one
+
+SELECT id
+FROM listings
+WHERE
+    simhash IS NOT NULL
+    AND created_at >= $1
+    AND bit_count((simhash # $2::bigint)::bit(64)) <= $3::integer
+    AND id <> $4
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type FindNearDuplicateListingParams struct {
+	Since       time.Time
+	Simhash     int64
+	MaxDistance int32
+	ExcludeID   int64
+}
+
+// =====================================================
+// NEAR-DUPLICATES (SIMHASH)
+// =====================================================
+// Hidden rows count too: a hidden spam must still block its reposts.
+// exclude_id skips the listing being edited (0 on create).
+func (q *Queries) FindNearDuplicateListing(ctx context.Context, arg FindNearDuplicateListingParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, findNearDuplicateListing,
+		arg.Since,
+		arg.Simhash,
+		arg.MaxDistance,
+		arg.ExcludeID,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getEditableListing = `-- name: GetEditableListing :one
+
+SELECT
+    id,
+    body,
+    is_shadowed
+FROM listings
+WHERE
+    id = $1
+    AND delete_token_hash = $2
+    AND (is_hidden = FALSE OR is_shadowed = TRUE)
+    AND created_at >= $3
+FOR UPDATE
+`
+
+type GetEditableListingParams struct {
+	ID              int64
+	DeleteTokenHash []byte
+	CreatedAfter    time.Time
+}
+
+type GetEditableListingRow struct {
+	ID         int64
+	Body       string
+	IsShadowed bool
+}
+
+// =====================================================
+// LISTING EDITS (AUTHOR TOKEN, TIME WINDOW)
+// =====================================================
+// Locks the row for the edit transaction. No row = unknown id,
+// wrong token, retracted, or window closed.
+func (q *Queries) GetEditableListing(ctx context.Context, arg GetEditableListingParams) (GetEditableListingRow, error) {
+	row := q.db.QueryRowContext(ctx, getEditableListing, arg.ID, arg.DeleteTokenHash, arg.CreatedAfter)
+	var i GetEditableListingRow
+	err := row.Scan(&i.ID, &i.Body, &i.IsShadowed)
+	return i, err
+}
+
+const getVisibleListing = `-- name: GetVisibleListing :one
+
+SELECT
+    id,
+    body,
+    created_at,
+    edited_at,
+    expires_at
+FROM listings
+WHERE
+    id = $1
+    AND is_pending = FALSE
+    AND (expires_at IS NULL OR expires_at > now())
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $2)
+    )
+`
+
+type GetVisibleListingParams struct {
+	ID           int64
+	ViewerIpHash []byte
+}
+
+type GetVisibleListingRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	EditedAt  sql.NullTime
+	ExpiresAt sql.NullTime
+}
+
+// Public single-listing read. Shadowed rows only for their source.
+func (q *Queries) GetVisibleListing(ctx context.Context, arg GetVisibleListingParams) (GetVisibleListingRow, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleListing, arg.ID, arg.ViewerIpHash)
+	var i GetVisibleListingRow
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.EditedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const hideExpiredListings = `-- name: HideExpiredListings :execrows
+
+UPDATE listings
+SET
+    is_hidden = TRUE,
+    hidden_at = expires_at,
+    is_pending = FALSE
+WHERE id IN (
+    SELECT id
+    FROM listings
+    WHERE
+        expires_at IS NOT NULL
+        AND is_hidden = FALSE
+        AND expires_at <= now()
+    ORDER BY expires_at
+    LIMIT $1
+)
+`
+
+// =====================================================
+// EXPIRY (BATCHED)
+// =====================================================
+func (q *Queries) HideExpiredListings(ctx context.Context, batchSize int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, hideExpiredListings, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const hideListing = `-- name: HideListing :one
+
+UPDATE listings
+SET
+    is_hidden = TRUE,
+    hidden_at = COALESCE(hidden_at, now()),
+    is_pending = FALSE,
+    is_shadowed = FALSE
+WHERE id = $1
+RETURNING
+    id,
+    is_hidden,
+    hidden_at
+`
+
+type HideListingRow struct {
+	ID       int64
+	IsHidden bool
+	HiddenAt sql.NullTime
+}
+
+// =====================================================
+// MODERATION (ADMIN)
+// =====================================================
+// Clearing is_shadowed hides a shadowed row from its source too.
+func (q *Queries) HideListing(ctx context.Context, id int64) (HideListingRow, error) {
+	row := q.db.QueryRowContext(ctx, hideListing, id)
+	var i HideListingRow
+	err := row.Scan(&i.ID, &i.IsHidden, &i.HiddenAt)
+	return i, err
+}
+
+const hideListingsByIP = `-- name: HideListingsByIP :many
+
+WITH hidden AS (
+    UPDATE listings
+    SET
+        is_hidden = TRUE,
+        hidden_at = COALESCE(hidden_at, now()),
+        is_pending = FALSE,
+        is_shadowed = FALSE
+    WHERE
+        ip_hash = $1
+        AND (is_hidden = FALSE OR is_shadowed = TRUE)
+        AND ($2::timestamptz IS NULL OR created_at >= $2)
+        AND ($3::timestamptz IS NULL OR created_at < $3)
+    RETURNING id
+), logged AS (
+    INSERT INTO moderation_events (listing_id, ip_hash, actor, action, reason)
+    SELECT id, $1, $4, $5, $6
+    FROM hidden
+)
+SELECT id
+FROM hidden
+`
+
+type HideListingsByIPParams struct {
+	IpHash []byte
+	Since  sql.NullTime
+	Until  sql.NullTime
+	Actor  string
+	Action string
+	Reason string
+}
+
+// Hides and writes one audit row per listing in a single statement,
+// so a flood of thousands of rows is still one round trip.
+func (q *Queries) HideListingsByIP(ctx context.Context, arg HideListingsByIPParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, hideListingsByIP,
+		arg.IpHash,
+		arg.Since,
+		arg.Until,
+		arg.Actor,
+		arg.Action,
+		arg.Reason,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertListingTerms = `-- name: InsertListingTerms :execrows
+
+INSERT INTO listing_terms (word, ndoc, nentry)
+SELECT word, ndoc, nentry
+FROM ts_stat(
+    'SELECT body_tsv
+     FROM listings
+     WHERE is_hidden = FALSE
+       AND is_pending = FALSE
+       AND (expires_at IS NULL OR expires_at > now())'
+)
+WHERE ndoc >= $1::integer
+`
+
+// ts_stat over the stored body_tsv, so nothing is parsed again and
+// every suggestion is a lexeme search indexes: the exact words and,
+// since 012, the language stems ("hous"). Words in fewer than
+// min_docs listings are left out, so one-off names and numbers are
+// never suggested.
+func (q *Queries) InsertListingTerms(ctx context.Context, minDocs int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertListingTerms, minDocs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listActiveBans = `-- name: ListActiveBans :many
+SELECT
+    ip_hash,
+    reason,
+    actor,
+    created_at,
+    expires_at,
+    shadow
+FROM bans
+WHERE
+    expires_at IS NULL
+    OR expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveBans(ctx context.Context) ([]Ban, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveBans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Ban{}
+	for rows.Next() {
+		var i Ban
+		if err := rows.Scan(
+			&i.IpHash,
+			&i.Reason,
+			&i.Actor,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Shadow,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listListingIDsByIP = `-- name: ListListingIDsByIP :many
+
+SELECT id
+FROM listings
+WHERE
+    ip_hash = $1
+    AND (is_hidden = FALSE OR is_shadowed = TRUE)
+    AND ($2::timestamptz IS NULL OR created_at >= $2)
+    AND ($3::timestamptz IS NULL OR created_at < $3)
+ORDER BY created_at DESC, id DESC
+`
+
+type ListListingIDsByIPParams struct {
+	IpHash []byte
+	Since  sql.NullTime
+	Until  sql.NullTime
+}
+
+// =====================================================
+// BULK MODERATION BY ip_hash
+// =====================================================
+// Both use idx_listings_ip_hash_created_at; the time range is optional.
+// Shadowed rows count as visible: their source still sees them.
+func (q *Queries) ListListingIDsByIP(ctx context.Context, arg ListListingIDsByIPParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listListingIDsByIP, arg.IpHash, arg.Since, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listListingRevisions = `-- name: ListListingRevisions :many
+SELECT
+    id,
+    body,
+    created_at
+FROM listing_revisions
+WHERE listing_id = $1
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListListingRevisionsParams struct {
+	ListingID int64
+	Limit     int32
+}
+
+type ListListingRevisionsRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListListingRevisions(ctx context.Context, arg ListListingRevisionsParams) ([]ListListingRevisionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listListingRevisions, arg.ListingID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListListingRevisionsRow{}
+	for rows.Next() {
+		var i ListListingRevisionsRow
+		if err := rows.Scan(&i.ID, &i.Body, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModerationEventsAfterCursor = `-- name: ListModerationEventsAfterCursor :many
+SELECT
+    id,
+    listing_id,
+    ip_hash,
+    actor,
+    action,
+    reason,
+    created_at
+FROM moderation_events
+WHERE
+    (
+        $1::bigint IS NULL
+        OR listing_id = $1
+    )
+    AND (
+        created_at < $2
+        OR (created_at = $2 AND id < $3)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListModerationEventsAfterCursorParams struct {
+	ListingID sql.NullInt64
+	CreatedAt time.Time
+	ID        int64
+	RowLimit  int32
+}
+
+func (q *Queries) ListModerationEventsAfterCursor(ctx context.Context, arg ListModerationEventsAfterCursorParams) ([]ModerationEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationEventsAfterCursor,
+		arg.ListingID,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ModerationEvent{}
+	for rows.Next() {
+		var i ModerationEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.IpHash,
+			&i.Actor,
+			&i.Action,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModerationEventsFirstPage = `-- name: ListModerationEventsFirstPage :many
+SELECT
+    id,
+    listing_id,
+    ip_hash,
+    actor,
+    action,
+    reason,
+    created_at
+FROM moderation_events
+WHERE
+    $1::bigint IS NULL
+    OR listing_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type ListModerationEventsFirstPageParams struct {
+	ListingID sql.NullInt64
+	RowLimit  int32
+}
+
+func (q *Queries) ListModerationEventsFirstPage(ctx context.Context, arg ListModerationEventsFirstPageParams) ([]ModerationEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationEventsFirstPage, arg.ListingID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ModerationEvent{}
+	for rows.Next() {
+		var i ModerationEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.IpHash,
+			&i.Actor,
+			&i.Action,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingAfterCursor = `-- name: ListPendingAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    ip_hash,
+    link_count
+FROM listings
+WHERE
+    is_pending = TRUE
+    AND (
+        created_at > $1
+        OR (created_at = $1 AND id > $2)
+    )
+ORDER BY created_at, id
+LIMIT $3
+`
+
+type ListPendingAfterCursorParams struct {
+	CreatedAt time.Time
+	ID        int64
+	Limit     int32
+}
+
+type ListPendingAfterCursorRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	IpHash    []byte
+	LinkCount sql.NullInt32
+}
+
+func (q *Queries) ListPendingAfterCursor(ctx context.Context, arg ListPendingAfterCursorParams) ([]ListPendingAfterCursorRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingAfterCursor, arg.CreatedAt, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPendingAfterCursorRow{}
+	for rows.Next() {
+		var i ListPendingAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.IpHash,
+			&i.LinkCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingFirstPage = `-- name: ListPendingFirstPage :many
+
+SELECT
+    id,
+    body,
+    created_at,
+    ip_hash,
+    link_count
+FROM listings
+WHERE is_pending = TRUE
+ORDER BY created_at, id
+LIMIT $1
+`
+
+type ListPendingFirstPageRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	IpHash    []byte
+	LinkCount sql.NullInt32
+}
+
+// =====================================================
+// PRE-MODERATION QUEUE (OLDEST FIRST)
+// =====================================================
+func (q *Queries) ListPendingFirstPage(ctx context.Context, limit int32) ([]ListPendingFirstPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingFirstPage, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPendingFirstPageRow{}
+	for rows.Next() {
+		var i ListPendingFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.IpHash,
+			&i.LinkCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listingExistsByIP = `-- name: ListingExistsByIP :one
+SELECT EXISTS (
+    SELECT 1
+    FROM listings
+    WHERE ip_hash = $1
+)
+`
+
+func (q *Queries) ListingExistsByIP(ctx context.Context, ipHash []byte) (bool, error) {
+	row := q.db.QueryRowContext(ctx, listingExistsByIP, ipHash)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listingStats = `-- name: ListingStats :one
+
+SELECT
+    COUNT(*) AS total,
+    COUNT(*) FILTER (
+        WHERE is_hidden = FALSE
+            AND is_pending = FALSE
+            AND (expires_at IS NULL OR expires_at > now())
+    ) AS visible,
+    COUNT(*) FILTER (WHERE is_hidden = TRUE) AS hidden,
+    COUNT(*) FILTER (WHERE is_pending = TRUE) AS pending,
+    COUNT(*) FILTER (
+        WHERE created_at >= now() - INTERVAL '1 day'
+    ) AS last_day
+FROM listings
+`
+
+type ListingStatsRow struct {
+	Total   int64
+	Visible int64
+	Hidden  int64
+	Pending int64
+	LastDay int64
+}
+
+// Operator overview (server stats subcommand)
+func (q *Queries) ListingStats(ctx context.Context) (ListingStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, listingStats)
+	var i ListingStatsRow
+	err := row.Scan(
+		&i.Total,
+		&i.Visible,
+		&i.Hidden,
+		&i.Pending,
+		&i.LastDay,
+	)
+	return i, err
+}
+
+const purgeHiddenListings = `-- name: PurgeHiddenListings :one
+
+WITH purged AS (
+    DELETE FROM listings
+    WHERE id IN (
+        SELECT id
+        FROM listings
+        WHERE
+            is_hidden = TRUE
+            AND hidden_at < $1
+        ORDER BY hidden_at
+        LIMIT $2
+    )
+    RETURNING id, ip_hash
+), logged AS (
+    INSERT INTO moderation_events (listing_id, ip_hash, actor, action, reason)
+    SELECT id, ip_hash, $3, $4, $5
+    FROM purged
+    WHERE $3::text <> ''
+)
+SELECT count(*)
+FROM purged
+`
+
+type PurgeHiddenListingsParams struct {
+	HiddenBefore time.Time
+	BatchSize    int32
+	Actor        string
+	Action       string
+	Reason       string
+}
+
+// =====================================================
+// RETENTION (BATCHED)
+// =====================================================
+// An empty actor skips the audit rows (the scheduled job); the CLI
+// logs one row per purged listing, like any other change.
+func (q *Queries) PurgeHiddenListings(ctx context.Context, arg PurgeHiddenListingsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, purgeHiddenListings,
+		arg.HiddenBefore,
+		arg.BatchSize,
+		arg.Actor,
+		arg.Action,
+		arg.Reason,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const rejectPendingListing = `-- name: RejectPendingListing :execrows
+UPDATE listings
+SET
+    is_pending = FALSE,
+    is_hidden = TRUE,
+    hidden_at = now()
+WHERE
+    id = $1
+    AND is_pending = TRUE
+`
+
+func (q *Queries) RejectPendingListing(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rejectPendingListing, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const retractListing = `-- name: RetractListing :execrows
+
+UPDATE listings
+SET
+    is_hidden = TRUE,
+    hidden_at = COALESCE(hidden_at, now()),
+    is_pending = FALSE,
+    is_shadowed = FALSE,
+    delete_token_hash = NULL
+WHERE
+    id = $1
+    AND delete_token_hash = $2
+    AND (is_hidden = FALSE OR is_shadowed = TRUE)
+`
+
+type RetractListingParams struct {
+	ID              int64
+	DeleteTokenHash []byte
+}
+
+// Author retraction: hides the listing and burns the token.
+// Shadowed rows are accepted too, so a shadow ban does not leak;
+// clearing is_shadowed keeps them out of the author's own view.
+func (q *Queries) RetractListing(ctx context.Context, arg RetractListingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, retractListing, arg.ID, arg.DeleteTokenHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchListingsAfterCursor = `-- name: SearchListingsAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    CASE
+        WHEN $1::text <> '' THEN ts_headline(
+            $2::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery($3, $4, $2),
+            $1
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $5)
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($6::timestamptz IS NULL OR created_at >= $6)
+    AND ($7::timestamptz IS NULL OR created_at < $7)
+    AND ($8::boolean IS NULL OR has_links = $8)
+    AND ($9::integer IS NULL OR body_length >= $9)
+    AND ($10::integer IS NULL OR body_length <= $10)
+    AND ($11::integer IS NULL OR link_count <= $11)
+    AND (
+        $3::text IS NULL
+        OR body_tsv @@ listing_tsquery($3, $4, $2)
+    )
+    AND (
+        created_at < $12
+        OR (created_at = $12 AND id < $13)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $14
+`
+
+type SearchListingsAfterCursorParams struct {
+	HeadlineOptions string
+	Lang            string
+	Q               string
+	Web             bool
+	ViewerIpHash    []byte
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasLinks        sql.NullBool
+	MinLength       sql.NullInt32
+	MaxLength       sql.NullInt32
+	MaxLinks        sql.NullInt32
+	CreatedAt       time.Time
+	ID              int64
+	RowLimit        int32
+}
+
+type SearchListingsAfterCursorRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Snippet   sql.NullString
+}
+
+func (q *Queries) SearchListingsAfterCursor(ctx context.Context, arg SearchListingsAfterCursorParams) ([]SearchListingsAfterCursorRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsAfterCursor,
+		arg.HeadlineOptions,
+		arg.Lang,
+		arg.Q,
+		arg.Web,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchListingsAfterCursorRow{}
+	for rows.Next() {
+		var i SearchListingsAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchListingsAscAfterCursor = `-- name: SearchListingsAscAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    CASE
+        WHEN $1::text <> '' THEN ts_headline(
+            $2::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery($3, $4, $2),
+            $1
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $5)
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($6::timestamptz IS NULL OR created_at >= $6)
+    AND ($7::timestamptz IS NULL OR created_at < $7)
+    AND ($8::boolean IS NULL OR has_links = $8)
+    AND ($9::integer IS NULL OR body_length >= $9)
+    AND ($10::integer IS NULL OR body_length <= $10)
+    AND ($11::integer IS NULL OR link_count <= $11)
+    AND (
+        $3::text IS NULL
+        OR body_tsv @@ listing_tsquery($3, $4, $2)
+    )
+    AND (
+        created_at > $12
+        OR (created_at = $12 AND id > $13)
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT $14
+`
+
+type SearchListingsAscAfterCursorParams struct {
+	HeadlineOptions string
+	Lang            string
+	Q               string
+	Web             bool
+	ViewerIpHash    []byte
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasLinks        sql.NullBool
+	MinLength       sql.NullInt32
+	MaxLength       sql.NullInt32
+	MaxLinks        sql.NullInt32
+	CreatedAt       time.Time
+	ID              int64
+	RowLimit        int32
+}
+
+type SearchListingsAscAfterCursorRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Snippet   sql.NullString
+}
+
+func (q *Queries) SearchListingsAscAfterCursor(ctx context.Context, arg SearchListingsAscAfterCursorParams) ([]SearchListingsAscAfterCursorRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsAscAfterCursor,
+		arg.HeadlineOptions,
+		arg.Lang,
+		arg.Q,
+		arg.Web,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchListingsAscAfterCursorRow{}
+	for rows.Next() {
+		var i SearchListingsAscAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchListingsAscFirstPage = `-- name: SearchListingsAscFirstPage :many
+
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    CASE
+        WHEN $1::text <> '' THEN ts_headline(
+            $2::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery($3, $4, $2),
+            $1
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $5)
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($6::timestamptz IS NULL OR created_at >= $6)
+    AND ($7::timestamptz IS NULL OR created_at < $7)
+    AND ($8::boolean IS NULL OR has_links = $8)
+    AND ($9::integer IS NULL OR body_length >= $9)
+    AND ($10::integer IS NULL OR body_length <= $10)
+    AND ($11::integer IS NULL OR link_count <= $11)
+    AND (
+        $3::text IS NULL
+        OR body_tsv @@ listing_tsquery($3, $4, $2)
+    )
+ORDER BY created_at ASC, id ASC
+LIMIT $12
+`
+
+type SearchListingsAscFirstPageParams struct {
+	HeadlineOptions string
+	Lang            string
+	Q               string
+	Web             bool
+	ViewerIpHash    []byte
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasLinks        sql.NullBool
+	MinLength       sql.NullInt32
+	MaxLength       sql.NullInt32
+	MaxLinks        sql.NullInt32
+	RowLimit        int32
+}
+
+type SearchListingsAscFirstPageRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Snippet   sql.NullString
+}
+
+// Oldest first (order=asc), and the scan behind prev_cursor for the
+// newest-first order. The (created_at DESC, id DESC) indexes are
+// read backwards.
+func (q *Queries) SearchListingsAscFirstPage(ctx context.Context, arg SearchListingsAscFirstPageParams) ([]SearchListingsAscFirstPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsAscFirstPage,
+		arg.HeadlineOptions,
+		arg.Lang,
+		arg.Q,
+		arg.Web,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchListingsAscFirstPageRow{}
+	for rows.Next() {
+		var i SearchListingsAscFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchListingsByRankAfterCursor = `-- name: SearchListingsByRankAfterCursor :many
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    ts_rank_cd(body_tsv, listing_tsquery($1, $2, $3))::real AS rank,
+    CASE
+        WHEN $4::text <> '' THEN ts_headline(
+            $3::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery($1, $2, $3),
+            $4
+        )
+    END AS snippet
 FROM listings
 WHERE
-    ip_hash = $1
-    AND created_at >= now() - INTERVAL '1 hour'
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $5)
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($6::timestamptz IS NULL OR created_at >= $6)
+    AND ($7::timestamptz IS NULL OR created_at < $7)
+    AND ($8::boolean IS NULL OR has_links = $8)
+    AND ($9::integer IS NULL OR body_length >= $9)
+    AND ($10::integer IS NULL OR body_length <= $10)
+    AND ($11::integer IS NULL OR link_count <= $11)
+    AND body_tsv @@ listing_tsquery($1, $2, $3)
+    AND (
+        ts_rank_cd(body_tsv, listing_tsquery($1, $2, $3))::real < $12::real
+        OR (
+            ts_rank_cd(body_tsv, listing_tsquery($1, $2, $3))::real = $12::real
+            AND (
+                created_at < $13
+                OR (created_at = $13 AND id < $14)
+            )
+        )
+    )
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $15
 `
 
-// =====================================================
-// BOT / RATE LIMITING HELPERS
-// =====================================================
-func (q *Queries) CountRecentListingsByIP(ctx context.Context, ipHash []byte) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countRecentListingsByIP, ipHash)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type SearchListingsByRankAfterCursorParams struct {
+	Q               string
+	Web             bool
+	Lang            string
+	HeadlineOptions string
+	ViewerIpHash    []byte
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasLinks        sql.NullBool
+	MinLength       sql.NullInt32
+	MaxLength       sql.NullInt32
+	MaxLinks        sql.NullInt32
+	Rank            float32
+	CreatedAt       time.Time
+	ID              int64
+	RowLimit        int32
 }
 
-const countVisibleListings = `-- name: CountVisibleListings :one
+type SearchListingsByRankAfterCursorRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Rank      float32
+	Snippet   sql.NullString
+}
 
-SELECT COUNT(*)::bigint
+func (q *Queries) SearchListingsByRankAfterCursor(ctx context.Context, arg SearchListingsByRankAfterCursorParams) ([]SearchListingsByRankAfterCursorRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsByRankAfterCursor,
+		arg.Q,
+		arg.Web,
+		arg.Lang,
+		arg.HeadlineOptions,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.Rank,
+		arg.CreatedAt,
+		arg.ID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchListingsByRankAfterCursorRow{}
+	for rows.Next() {
+		var i SearchListingsByRankAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchListingsByRankFirstPage = `-- name: SearchListingsByRankFirstPage :many
+
+SELECT
+    id,
+    body,
+    created_at,
+    expires_at,
+    ts_rank_cd(body_tsv, listing_tsquery($1, $2, $3))::real AS rank,
+    CASE
+        WHEN $4::text <> '' THEN ts_headline(
+            $3::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery($1, $2, $3),
+            $4
+        )
+    END AS snippet
 FROM listings
-WHERE is_hidden = FALSE
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $5)
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($6::timestamptz IS NULL OR created_at >= $6)
+    AND ($7::timestamptz IS NULL OR created_at < $7)
+    AND ($8::boolean IS NULL OR has_links = $8)
+    AND ($9::integer IS NULL OR body_length >= $9)
+    AND ($10::integer IS NULL OR body_length <= $10)
+    AND ($11::integer IS NULL OR link_count <= $11)
+    AND body_tsv @@ listing_tsquery($1, $2, $3)
+ORDER BY rank DESC, created_at DESC, id DESC
+LIMIT $12
 `
 
-// =====================================================
-// Global counter for DB entries above search
-// =====================================================
-func (q *Queries) CountVisibleListings(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countVisibleListings)
-	var column_1 int64
-	err := row.Scan(&column_1)
-	return column_1, err
+type SearchListingsByRankFirstPageParams struct {
+	Q               string
+	Web             bool
+	Lang            string
+	HeadlineOptions string
+	ViewerIpHash    []byte
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasLinks        sql.NullBool
+	MinLength       sql.NullInt32
+	MaxLength       sql.NullInt32
+	MaxLinks        sql.NullInt32
+	RowLimit        int32
 }
 
-const createListing = `-- name: CreateListing :one
+type SearchListingsByRankFirstPageRow struct {
+	ID        int64
+	Body      string
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Rank      float32
+	Snippet   sql.NullString
+}
 
-INSERT INTO listings (
-    body,
-    ip_hash
-) VALUES (
-    $1,
-    $2
-)
-RETURNING
+// Relevance order: ts_rank_cd over the same match, ties broken by
+// (created_at, id) so the keyset stays total.
+func (q *Queries) SearchListingsByRankFirstPage(ctx context.Context, arg SearchListingsByRankFirstPageParams) ([]SearchListingsByRankFirstPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsByRankFirstPage,
+		arg.Q,
+		arg.Web,
+		arg.Lang,
+		arg.HeadlineOptions,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchListingsByRankFirstPageRow{}
+	for rows.Next() {
+		var i SearchListingsByRankFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchListingsFirstPage = `-- name: SearchListingsFirstPage :many
+
+SELECT
     id,
     body,
-    is_hidden,
-    created_at
+    created_at,
+    expires_at,
+    CASE
+        WHEN $1::text <> '' THEN ts_headline(
+            $2::regconfig,
+            translate(body, chr(1) || chr(2), ''),
+            listing_tsquery($3, $4, $2),
+            $1
+        )
+    END AS snippet
+FROM listings
+WHERE
+    is_pending = FALSE
+    AND (
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $5)
+    )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($6::timestamptz IS NULL OR created_at >= $6)
+    AND ($7::timestamptz IS NULL OR created_at < $7)
+    AND ($8::boolean IS NULL OR has_links = $8)
+    AND ($9::integer IS NULL OR body_length >= $9)
+    AND ($10::integer IS NULL OR body_length <= $10)
+    AND ($11::integer IS NULL OR link_count <= $11)
+    AND (
+        $3::text IS NULL
+        OR body_tsv @@ listing_tsquery($3, $4, $2)
+    )
+ORDER BY created_at DESC, id DESC
+LIMIT $12
 `
 
-type CreateListingParams struct {
-	Body   string
-	IpHash []byte
+type SearchListingsFirstPageParams struct {
+	HeadlineOptions string
+	Lang            string
+	Q               string
+	Web             bool
+	ViewerIpHash    []byte
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasLinks        sql.NullBool
+	MinLength       sql.NullInt32
+	MaxLength       sql.NullInt32
+	MaxLinks        sql.NullInt32
+	RowLimit        int32
 }
 
-type CreateListingRow struct {
+type SearchListingsFirstPageRow struct {
 	ID        int64
 	Body      string
-	IsHidden  bool
 	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Snippet   sql.NullString
 }
 
 // =====================================================
-// LISTINGS
+// LISTINGS SEARCH (KEYSET PAGINATION)
 // =====================================================
-func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (CreateListingRow, error) {
-	row := q.db.QueryRowContext(ctx, createListing, arg.Body, arg.IpHash)
-	var i CreateListingRow
-	err := row.Scan(
-		&i.ID,
-		&i.Body,
-		&i.IsHidden,
-		&i.CreatedAt,
+// Every search below takes the same optional filters (NULL = off):
+// created_at in [since, until), has_links, body_length in
+// [min_length, max_length], link_count <= max_links. They only narrow
+// the rows; the keyset condition is unchanged.
+// Visibility is the same as GetVisibleListing: public rows, plus the
+// shadowed rows of viewer_ip_hash, a shadow-banned source searching
+// (NULL for everyone else). Its rows sort and page like any other,
+// so each order needs no second query.
+// snippet: ts_headline of the match when headline_options is not
+// empty (highlight=1), NULL otherwise; built only for returned rows.
+// \x01/\x02 are the match markers, so they are stripped from the body.
+func (q *Queries) SearchListingsFirstPage(ctx context.Context, arg SearchListingsFirstPageParams) ([]SearchListingsFirstPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsFirstPage,
+		arg.HeadlineOptions,
+		arg.Lang,
+		arg.Q,
+		arg.Web,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.RowLimit,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchListingsFirstPageRow{}
+	for rows.Next() {
+		var i SearchListingsFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const searchListingsAfterCursor = `-- name: SearchListingsAfterCursor :many
+const searchListingsFuzzyAfterCursor = `-- name: SearchListingsFuzzyAfterCursor :many
 SELECT
     id,
     body,
-    created_at
+    created_at,
+    expires_at,
+    word_similarity($1, body)::real AS score
 FROM listings
 WHERE
-    is_hidden = FALSE
+    is_pending = FALSE
     AND (
-        $1::text IS NULL
-        OR body_tsv @@ plainto_tsquery('simple', $1)
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $2)
     )
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($3::timestamptz IS NULL OR created_at >= $3)
+    AND ($4::timestamptz IS NULL OR created_at < $4)
+    AND ($5::boolean IS NULL OR has_links = $5)
+    AND ($6::integer IS NULL OR body_length >= $6)
+    AND ($7::integer IS NULL OR body_length <= $7)
+    AND ($8::integer IS NULL OR link_count <= $8)
     AND (
-        created_at < $2
-        OR (created_at = $2 AND id < $3)
+        $1::text <% body
+        OR body ILIKE $9::text
     )
-ORDER BY created_at DESC, id DESC
-LIMIT $4
+    AND (
+        word_similarity($1, body)::real < $10::real
+        OR (
+            word_similarity($1, body)::real = $10::real
+            AND (
+                created_at < $11
+                OR (created_at = $11 AND id < $12)
+            )
+        )
+    )
+ORDER BY score DESC, created_at DESC, id DESC
+LIMIT $13
 `
 
-type SearchListingsAfterCursorParams struct {
-	Column1   string
-	CreatedAt time.Time
-	ID        int64
-	Limit     int32
+type SearchListingsFuzzyAfterCursorParams struct {
+	Q            string
+	ViewerIpHash []byte
+	Since        sql.NullTime
+	Until        sql.NullTime
+	HasLinks     sql.NullBool
+	MinLength    sql.NullInt32
+	MaxLength    sql.NullInt32
+	MaxLinks     sql.NullInt32
+	Pattern      string
+	Score        float32
+	CreatedAt    time.Time
+	ID           int64
+	RowLimit     int32
 }
 
-type SearchListingsAfterCursorRow struct {
+type SearchListingsFuzzyAfterCursorRow struct {
 	ID        int64
 	Body      string
 	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Score     float32
 }
 
-func (q *Queries) SearchListingsAfterCursor(ctx context.Context, arg SearchListingsAfterCursorParams) ([]SearchListingsAfterCursorRow, error) {
-	rows, err := q.db.QueryContext(ctx, searchListingsAfterCursor,
-		arg.Column1,
+func (q *Queries) SearchListingsFuzzyAfterCursor(ctx context.Context, arg SearchListingsFuzzyAfterCursorParams) ([]SearchListingsFuzzyAfterCursorRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsFuzzyAfterCursor,
+		arg.Q,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.Pattern,
+		arg.Score,
 		arg.CreatedAt,
 		arg.ID,
-		arg.Limit,
+		arg.RowLimit,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []SearchListingsAfterCursorRow{}
+	items := []SearchListingsFuzzyAfterCursorRow{}
 	for rows.Next() {
-		var i SearchListingsAfterCursorRow
-		if err := rows.Scan(&i.ID, &i.Body, &i.CreatedAt); err != nil {
+		var i SearchListingsFuzzyAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Score,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -150,47 +2082,132 @@ func (q *Queries) SearchListingsAfterCursor(ctx context.Context, arg SearchListi
 	return items, nil
 }
 
-const searchListingsFirstPage = `-- name: SearchListingsFirstPage :many
+const searchListingsFuzzyFirstPage = `-- name: SearchListingsFuzzyFirstPage :many
 
 SELECT
     id,
     body,
-    created_at
+    created_at,
+    expires_at,
+    word_similarity($1, body)::real AS score
 FROM listings
 WHERE
-    is_hidden = FALSE
+    is_pending = FALSE
     AND (
-        $1::text IS NULL
-        OR body_tsv @@ plainto_tsquery('simple', $1)
+        is_hidden = FALSE
+        OR (is_shadowed = TRUE AND ip_hash = $2)
     )
-ORDER BY created_at DESC, id DESC
-LIMIT $2
+    AND (expires_at IS NULL OR expires_at > now())
+    AND ($3::timestamptz IS NULL OR created_at >= $3)
+    AND ($4::timestamptz IS NULL OR created_at < $4)
+    AND ($5::boolean IS NULL OR has_links = $5)
+    AND ($6::integer IS NULL OR body_length >= $6)
+    AND ($7::integer IS NULL OR body_length <= $7)
+    AND ($8::integer IS NULL OR link_count <= $8)
+    AND (
+        $1::text <% body
+        OR body ILIKE $9::text
+    )
+ORDER BY score DESC, created_at DESC, id DESC
+LIMIT $10
 `
 
-type SearchListingsFirstPageParams struct {
-	Column1 string
-	Limit   int32
+type SearchListingsFuzzyFirstPageParams struct {
+	Q            string
+	ViewerIpHash []byte
+	Since        sql.NullTime
+	Until        sql.NullTime
+	HasLinks     sql.NullBool
+	MinLength    sql.NullInt32
+	MaxLength    sql.NullInt32
+	MaxLinks     sql.NullInt32
+	Pattern      string
+	RowLimit     int32
 }
 
-type SearchListingsFirstPageRow struct {
+type SearchListingsFuzzyFirstPageRow struct {
 	ID        int64
 	Body      string
 	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+	Score     float32
 }
 
-// =====================================================
-// LISTINGS SEARCH (KEYSET PAGINATION)
-// =====================================================
-func (q *Queries) SearchListingsFirstPage(ctx context.Context, arg SearchListingsFirstPageParams) ([]SearchListingsFirstPageRow, error) {
-	rows, err := q.db.QueryContext(ctx, searchListingsFirstPage, arg.Column1, arg.Limit)
+// Fuzzy order (mode=fuzzy): trigram word similarity for typos and
+// partial words, ILIKE for exact substrings such as phone numbers.
+// pattern is q with LIKE wildcards escaped, wrapped in %...%.
+func (q *Queries) SearchListingsFuzzyFirstPage(ctx context.Context, arg SearchListingsFuzzyFirstPageParams) ([]SearchListingsFuzzyFirstPageRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchListingsFuzzyFirstPage,
+		arg.Q,
+		arg.ViewerIpHash,
+		arg.Since,
+		arg.Until,
+		arg.HasLinks,
+		arg.MinLength,
+		arg.MaxLength,
+		arg.MaxLinks,
+		arg.Pattern,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []SearchListingsFirstPageRow{}
+	items := []SearchListingsFuzzyFirstPageRow{}
 	for rows.Next() {
-		var i SearchListingsFirstPageRow
-		if err := rows.Scan(&i.ID, &i.Body, &i.CreatedAt); err != nil {
+		var i SearchListingsFuzzyFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.ExpiresAt,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const suggestListingTerms = `-- name: SuggestListingTerms :many
+
+SELECT
+    word,
+    ndoc
+FROM listing_terms
+WHERE word LIKE $1::text
+ORDER BY ndoc DESC, word
+LIMIT $2
+`
+
+type SuggestListingTermsParams struct {
+	Pattern  string
+	RowLimit int32
+}
+
+type SuggestListingTermsRow struct {
+	Word string
+	Ndoc int32
+}
+
+// pattern is the prefix with LIKE wildcards escaped, plus '%'.
+func (q *Queries) SuggestListingTerms(ctx context.Context, arg SuggestListingTermsParams) ([]SuggestListingTermsRow, error) {
+	rows, err := q.db.QueryContext(ctx, suggestListingTerms, arg.Pattern, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SuggestListingTermsRow{}
+	for rows.Next() {
+		var i SuggestListingTermsRow
+		if err := rows.Scan(&i.Word, &i.Ndoc); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -214,3 +2231,147 @@ func (q *Queries) TouchListingsByIP(ctx context.Context, ipHash []byte) error {
 	_, err := q.db.ExecContext(ctx, touchListingsByIP, ipHash)
 	return err
 }
+
+const unhideListing = `-- name: UnhideListing :one
+UPDATE listings
+SET
+    is_hidden = FALSE,
+    hidden_at = NULL,
+    is_shadowed = FALSE
+WHERE id = $1
+RETURNING
+    id,
+    is_hidden,
+    hidden_at
+`
+
+type UnhideListingRow struct {
+	ID       int64
+	IsHidden bool
+	HiddenAt sql.NullTime
+}
+
+func (q *Queries) UnhideListing(ctx context.Context, id int64) (UnhideListingRow, error) {
+	row := q.db.QueryRowContext(ctx, unhideListing, id)
+	var i UnhideListingRow
+	err := row.Scan(&i.ID, &i.IsHidden, &i.HiddenAt)
+	return i, err
+}
+
+const updateListingBody = `-- name: UpdateListingBody :one
+UPDATE listings
+SET
+    body = $1,
+    simhash = $2,
+    is_pending = is_pending OR $3::boolean,
+    is_hidden = is_hidden OR $4::boolean,
+    hidden_at = CASE WHEN $4::boolean THEN COALESCE(hidden_at, now()) ELSE hidden_at END,
+    edited_at = now()
+WHERE id = $5
+RETURNING
+    id,
+    body,
+    is_hidden,
+    is_pending,
+    created_at,
+    edited_at
+`
+
+type UpdateListingBodyParams struct {
+	Body    string
+	Simhash sql.NullInt64
+	Hold    bool
+	Hide    bool
+	ID      int64
+}
+
+type UpdateListingBodyRow struct {
+	ID        int64
+	Body      string
+	IsHidden  bool
+	IsPending bool
+	CreatedAt time.Time
+	EditedAt  sql.NullTime
+}
+
+func (q *Queries) UpdateListingBody(ctx context.Context, arg UpdateListingBodyParams) (UpdateListingBodyRow, error) {
+	row := q.db.QueryRowContext(ctx, updateListingBody,
+		arg.Body,
+		arg.Simhash,
+		arg.Hold,
+		arg.Hide,
+		arg.ID,
+	)
+	var i UpdateListingBodyRow
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.IsHidden,
+		&i.IsPending,
+		&i.CreatedAt,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const upsertBan = `-- name: UpsertBan :one
+
+INSERT INTO bans (
+    ip_hash,
+    reason,
+    actor,
+    expires_at,
+    shadow
+) VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (ip_hash) DO UPDATE
+SET
+    reason = EXCLUDED.reason,
+    actor = EXCLUDED.actor,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at,
+    shadow = EXCLUDED.shadow
+RETURNING
+    ip_hash,
+    reason,
+    actor,
+    created_at,
+    expires_at,
+    shadow
+`
+
+type UpsertBanParams struct {
+	IpHash    []byte
+	Reason    string
+	Actor     string
+	ExpiresAt sql.NullTime
+	Shadow    bool
+}
+
+// =====================================================
+// BANS
+// =====================================================
+func (q *Queries) UpsertBan(ctx context.Context, arg UpsertBanParams) (Ban, error) {
+	row := q.db.QueryRowContext(ctx, upsertBan,
+		arg.IpHash,
+		arg.Reason,
+		arg.Actor,
+		arg.ExpiresAt,
+		arg.Shadow,
+	)
+	var i Ban
+	err := row.Scan(
+		&i.IpHash,
+		&i.Reason,
+		&i.Actor,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.Shadow,
+	)
+	return i, err
+}
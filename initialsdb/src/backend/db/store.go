@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 )
 
@@ -30,3 +31,18 @@ func (s *Store) WithTx(tx *sql.Tx) *Store {
 		db:      s.db,
 	}
 }
+
+// ExecTx runs fn inside one transaction: commit on nil, rollback otherwise.
+func (s *Store) ExecTx(ctx context.Context, fn func(*Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
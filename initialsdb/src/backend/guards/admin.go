@@ -0,0 +1,85 @@
+package guards
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+//
+// ──────────────────────────────────────────────
+// Config
+// ──────────────────────────────────────────────
+//
+
+type AdminConfig struct {
+	Enable bool
+	Tokens map[string]string // actor name → bearer token
+}
+
+//
+// ──────────────────────────────────────────────
+// Guard (bearer token per moderator)
+// ──────────────────────────────────────────────
+//
+
+// AdminGuard admits requests carrying "Authorization: Bearer <token>"
+// for one of the configured moderators. Unlike the other guards it
+// fails closed: when disabled, nobody gets in.
+type AdminGuard struct {
+	enable bool
+	tokens map[string]string
+}
+
+type adminActorKey struct{}
+
+func NewAdminGuard(cfg AdminConfig) *AdminGuard {
+	return &AdminGuard{
+		enable: cfg.Enable,
+		tokens: cfg.Tokens,
+	}
+}
+
+func (g *AdminGuard) Check(r *http.Request) bool {
+	if !g.enable {
+		return false
+	}
+
+	auth := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok {
+		return false
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return false
+	}
+
+	// Compare against every token so timing does not reveal
+	// which moderator (if any) matched.
+	actor := ""
+	for name, want := range g.tokens {
+		if want == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1 {
+			actor = name
+		}
+	}
+
+	if actor == "" {
+		return false
+	}
+
+	*r = *r.WithContext(context.WithValue(r.Context(), adminActorKey{}, actor))
+	return true
+}
+
+// AdminActor returns the moderator name attached by AdminGuard,
+// or "" when the request did not pass it.
+func AdminActor(r *http.Request) string {
+	actor, _ := r.Context().Value(adminActorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,175 @@
+package guards
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"app.root/db"
+)
+
+//
+// ──────────────────────────────────────────────
+// Config
+// ──────────────────────────────────────────────
+//
+
+type BanConfig struct {
+	Enable  bool
+	Salt    string        // server salt, same as for listings.ip_hash
+	Refresh time.Duration // how often the bans table is re-read
+}
+
+//
+// ──────────────────────────────────────────────
+// Guard (in-memory copy of the bans table)
+// ──────────────────────────────────────────────
+//
+
+// BanGuard rejects requests whose ip_hash is in the bans table.
+// Shadow bans pass the guard; handlers ask IsShadowBanned instead.
+// The table is cached in memory and re-read every cfg.Refresh,
+// or immediately via Refresh after an admin change.
+type BanGuard struct {
+	enable  bool
+	salt    string
+	refresh time.Duration
+	db      *sql.DB
+
+	mu     sync.RWMutex
+	banned map[string]banEntry // key: string(ip_hash)
+}
+
+type banEntry struct {
+	expiresAt time.Time // zero = permanent
+	shadow    bool
+}
+
+func NewBanGuard(cfg BanConfig, sqlDB *sql.DB) *BanGuard {
+	g := &BanGuard{
+		enable:  cfg.Enable,
+		salt:    cfg.Salt,
+		refresh: cfg.Refresh,
+		db:      sqlDB,
+		banned:  make(map[string]banEntry),
+	}
+
+	if !g.enable {
+		return g
+	}
+
+	if err := g.Refresh(context.Background()); err != nil {
+		fmt.Println("ban list refresh failed:", err)
+	}
+
+	if g.refresh > 0 {
+		go g.refreshLoop()
+	}
+
+	return g
+}
+
+func (g *BanGuard) Check(r *http.Request) bool {
+	if !g.enable {
+		return true
+	}
+
+	return !g.IsBanned(RequestIPHash(r, g.salt))
+}
+
+// IsBanned reports whether ipHash has an active (non-shadow) ban.
+func (g *BanGuard) IsBanned(ipHash []byte) bool {
+	e, ok := g.lookup(ipHash)
+	return ok && !e.shadow
+}
+
+// IsShadowBanned reports whether ipHash has an active shadow ban.
+// Safe on a nil guard.
+func (g *BanGuard) IsShadowBanned(ipHash []byte) bool {
+	if g == nil || !g.enable {
+		return false
+	}
+	e, ok := g.lookup(ipHash)
+	return ok && e.shadow
+}
+
+// ShadowBannedViewer returns the request's ip_hash when its source is
+// shadow-banned, nil otherwise. Safe on a nil guard.
+func (g *BanGuard) ShadowBannedViewer(r *http.Request) []byte {
+	if g == nil || !g.enable {
+		return nil
+	}
+	ipHash := RequestIPHash(r, g.salt)
+	if !g.IsShadowBanned(ipHash) {
+		return nil
+	}
+	return ipHash
+}
+
+func (g *BanGuard) lookup(ipHash []byte) (banEntry, bool) {
+	g.mu.RLock()
+	e, ok := g.banned[string(ipHash)]
+	g.mu.RUnlock()
+
+	if !ok {
+		return banEntry{}, false
+	}
+
+	// Expired between two refreshes
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		return banEntry{}, false
+	}
+
+	return e, true
+}
+
+//
+// ──────────────────────────────────────────────
+// Refresh
+// ──────────────────────────────────────────────
+//
+
+// Refresh replaces the cache with the active rows of the bans table.
+// On error the previous cache is kept.
+func (g *BanGuard) Refresh(ctx context.Context) error {
+	if !g.enable {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.New(g.db).ListActiveBans(ctx)
+	if err != nil {
+		return err
+	}
+
+	banned := make(map[string]banEntry, len(rows))
+	for _, b := range rows {
+		e := banEntry{shadow: b.Shadow}
+		if b.ExpiresAt.Valid {
+			e.expiresAt = b.ExpiresAt.Time
+		}
+		banned[string(b.IpHash)] = e
+	}
+
+	g.mu.Lock()
+	g.banned = banned
+	g.mu.Unlock()
+
+	return nil
+}
+
+func (g *BanGuard) refreshLoop() {
+	ticker := time.NewTicker(g.refresh)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := g.Refresh(context.Background()); err != nil {
+			fmt.Println("ban list refresh failed:", err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package guards
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strings"
+)
+
+//
+// ──────────────────────────────────────────────
+// ip_hash = sha256(ip + server salt)
+// ──────────────────────────────────────────────
+//
+
+// HashIP computes the ip_hash stored with every listing.
+// ip is expected in GetIP's normalized form.
+func HashIP(ip, salt string) []byte {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(ip) + salt))
+	return sum[:]
+}
+
+// RequestIPHash hashes the client IP of r exactly like CreateHandler.
+func RequestIPHash(r *http.Request, salt string) []byte {
+	return HashIP(GetIP(r), salt)
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"app.root/db"
+)
+
+/*
+Expiry hides listings whose expires_at has passed
+(idx_listings_expires_at). Reads already skip them; this makes it
+permanent and hands them to Retention, which purges hidden rows.
+hidden_at is set to expires_at, so the purge clock starts there.
+*/
+type Expiry struct {
+	DB *sql.DB
+}
+
+func (j Expiry) Run(ctx context.Context) error {
+	q := db.New(j.DB)
+
+	hidden, err := drain(ctx, func(ctx context.Context) (int64, error) {
+		return q.HideExpiredListings(ctx, retentionBatch)
+	})
+	if err != nil {
+		return fmt.Errorf("hide expired listings (%d done): %w", hidden, err)
+	}
+
+	if hidden > 0 {
+		fmt.Printf("expiry: hid %d expired listings\n", hidden)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Every runs fn now and then every interval until ctx is canceled.
+// Errors are logged; the next tick simply tries again.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			fmt.Printf("%s: %v\n", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"app.root/db"
+)
+
+// Rows per statement: keeps each DELETE/UPDATE short so it never
+// holds locks long enough to stall the request path.
+const retentionBatch = 1000
+
+/*
+Retention hard-deletes listings hidden for longer than PurgeHiddenAfter
+(idx_listings_hidden_at) and sets ip_hash to NULL on listings older
+than AnonymizeIPAfter. A zero duration disables that half.
+
+With AuditActor set, every purged listing gets a moderation_events
+row under that actor.
+*/
+type Retention struct {
+	DB               *sql.DB
+	PurgeHiddenAfter time.Duration
+	AnonymizeIPAfter time.Duration
+	AuditActor       string
+}
+
+// Audit log entry for a purged listing (see admin.Action*).
+const actionPurge = "purge"
+
+func (j Retention) Run(ctx context.Context) error {
+	q := db.New(j.DB)
+	now := time.Now()
+
+	var purged, anonymized int64
+
+	if j.PurgeHiddenAfter > 0 {
+		cutoff := now.Add(-j.PurgeHiddenAfter)
+		n, err := drain(ctx, func(ctx context.Context) (int64, error) {
+			return q.PurgeHiddenListings(ctx, db.PurgeHiddenListingsParams{
+				HiddenBefore: cutoff,
+				BatchSize:    retentionBatch,
+				Actor:        j.AuditActor,
+				Action:       actionPurge,
+				Reason:       "hidden before " + cutoff.UTC().Format(time.RFC3339),
+			})
+		})
+		purged = n
+		if err != nil {
+			return fmt.Errorf("purge hidden listings (%d done): %w", purged, err)
+		}
+	}
+
+	if j.AnonymizeIPAfter > 0 {
+		n, err := drain(ctx, func(ctx context.Context) (int64, error) {
+			return q.AnonymizeListingIPs(ctx, db.AnonymizeListingIPsParams{
+				CreatedBefore: now.Add(-j.AnonymizeIPAfter),
+				BatchSize:     retentionBatch,
+			})
+		})
+		anonymized = n
+		if err != nil {
+			return fmt.Errorf("anonymize ip hashes (%d done): %w", anonymized, err)
+		}
+	}
+
+	fmt.Printf("retention: purged %d hidden listings, anonymized %d ip hashes\n", purged, anonymized)
+	return nil
+}
+
+// drain repeats one batch until it affects fewer than retentionBatch rows.
+func drain(ctx context.Context, batch func(context.Context) (int64, error)) (int64, error) {
+	var total int64
+
+	for {
+		bctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		n, err := batch(bctx)
+		cancel()
+
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < retentionBatch {
+			return total, nil
+		}
+	}
+}
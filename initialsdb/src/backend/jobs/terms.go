@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"app.root/db"
+)
+
+/*
+Terms rebuilds listing_terms, the word counts behind
+/api/listings/suggest. ts_stat reads every visible listing, far too
+slow per request but fine every few minutes. The rebuild is one
+transaction, so suggestions never see a half-filled table.
+*/
+type Terms struct {
+	DB      *sql.DB
+	MinDocs int32 // words in fewer listings are not suggested
+}
+
+func (j Terms) Run(ctx context.Context) error {
+	err := db.NewStore(j.DB).ExecTx(ctx, func(s *db.Store) error {
+		if err := s.DeleteListingTerms(ctx); err != nil {
+			return err
+		}
+
+		_, err := s.InsertListingTerms(ctx, j.MinDocs)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("rebuild listing terms: %w", err)
+	}
+	return nil
+}
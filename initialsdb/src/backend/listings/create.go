@@ -2,26 +2,40 @@ package listings
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"app.root/config"
+	"app.root/content"
 	"app.root/db"
 	"app.root/guards"
 	"app.root/httpjson"
 )
 
 type CreateHandler struct {
-	DB     *sql.DB
-	Cfg    *config.Config
-	Guards []guards.Guard
+	DB      *sql.DB
+	Cfg     *config.Config
+	Guards  []guards.Guard
+	Content *content.Filter  // nil = no content policy
+	Bans    *guards.BanGuard // shadow bans; nil = none
 }
 
+// Audit log entries written by the create path (see admin.Action*).
+const (
+	systemActor    = "system"
+	actionHold     = "hold"
+	actionAutoHide = "auto_hide"
+
+	reasonShadowBan = "SHADOW_BAN"
+)
+
 type createListingRequest struct {
-	Text string `json:"text"`
+	Text          string `json:"text"`
+	Lang          string `json:"lang,omitempty"`            // ISO 639-1; "" = detect
+	ExpiresInSecs int64  `json:"expires_in_secs,omitempty"` // 0 = never
 }
 
 func (h *CreateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -49,22 +63,149 @@ func (h *CreateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ip := strings.TrimSpace(guards.GetIP(r))
-	ipHash := sha256.Sum256([]byte(ip + h.Cfg.ServerSalt))
+	var lang string
+	if req.Lang == "" {
+		lang = content.DetectLang(body)
+	} else {
+		cfg, ok := content.TextSearchConfig(req.Lang)
+		if !ok {
+			httpjson.BadRequest(w, "INVALID_INPUT", "unsupported lang")
+			return
+		}
+		lang = cfg
+	}
+
+	expires, ok := h.listingExpiry(req.ExpiresInSecs, time.Now())
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid expires_in_secs")
+		return
+	}
+
+	verdict := h.Content.Evaluate(body)
+	if verdict.Action == content.Reject {
+		httpjson.BadRequest(w, "CONTENT_REJECTED", verdict.Reason)
+		return
+	}
+
+	ipHash := guards.RequestIPHash(r, h.Cfg.ServerSalt)
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	store := db.NewStore(h.DB)
 
-	listing, err := store.CreateListing(ctx, db.CreateListingParams{
-		Body:   body,
-		IpHash: ipHash[:],
+	exceeded, err := h.quotaExceeded(ctx, store, ipHash)
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+	if exceeded {
+		httpjson.TooManyRequests(w, "QUOTA_EXCEEDED", "posting quota exceeded, try again later")
+		return
+	}
+
+	var simhash sql.NullInt64
+	if fp, ok := content.SimHash(body); ok {
+		simhash = sql.NullInt64{Int64: fp, Valid: true}
+	}
+
+	// Quarantined posts wait in the review queue; auto-hidden ones
+	// are stored hidden. Either way the reason goes to the audit log.
+	var (
+		hidden  bool
+		pending = verdict.Action == content.Quarantine
+		reason  = verdict.Reason
+	)
+
+	dupID, dup, err := nearDuplicate(ctx, store, h.Cfg.Dedup, simhash, 0)
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+	if dup {
+		if h.Cfg.Dedup.Action != "hide" {
+			httpjson.Conflict(w, "DUPLICATE_CONTENT", "a very similar listing was posted recently")
+			return
+		}
+		hidden = true
+		pending = false
+		reason = reasonNearDuplicate + " #" + strconv.FormatInt(dupID, 10)
+	}
+
+	if !hidden && !pending {
+		pending, reason, err = h.holdForReview(ctx, store, body, ipHash)
+		if err != nil {
+			httpjson.InternalError(w, "db error")
+			return
+		}
+	}
+
+	// Shadow-banned: stored hidden, but the author keeps seeing it
+	// (see SearchHandler) and gets the usual response.
+	shadowed := h.Bans.IsShadowBanned(ipHash)
+	if shadowed {
+		hidden = true
+		pending = false
+		reason = reasonShadowBan
+	}
+
+	deleteToken, deleteTokenHash, err := newDeleteToken()
+	if err != nil {
+		httpjson.InternalError(w, "token error")
+		return
+	}
+
+	var listing db.CreateListingRow
+
+	err = store.ExecTx(ctx, func(tx *db.Store) error {
+		var err error
+		listing, err = tx.CreateListing(ctx, db.CreateListingParams{
+			Body:            body,
+			IpHash:          ipHash,
+			IsHidden:        hidden,
+			Simhash:         simhash,
+			IsPending:       pending,
+			IsShadowed:      shadowed,
+			DeleteTokenHash: deleteTokenHash,
+			ExpiresAt:       expires,
+			Lang:            lang,
+		})
+		if err != nil || (!hidden && !pending) {
+			return err
+		}
+
+		action := actionHold
+		if hidden {
+			action = actionAutoHide
+		}
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			ListingID: sql.NullInt64{Int64: listing.ID, Valid: true},
+			IpHash:    ipHash,
+			Actor:     systemActor,
+			Action:    action,
+			Reason:    reason,
+		})
 	})
 	if err != nil {
 		httpjson.InternalError(w, "db error")
 		return
 	}
 
-	httpjson.WriteCreated(w, listing)
+	resp := CreatedListing{
+		ID:          listing.ID,
+		Body:        listing.Body,
+		CreatedAt:   listing.CreatedAt,
+		IsHidden:    listing.IsHidden && !shadowed,
+		IsPending:   listing.IsPending,
+		ExpiresAt:   expiresAt(listing.ExpiresAt),
+		DeleteToken: deleteToken,
+	}
+
+	if pending {
+		httpjson.Write(w, http.StatusAccepted, resp)
+		return
+	}
+
+	httpjson.WriteCreated(w, resp)
 }
@@ -0,0 +1,41 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"app.root/config"
+	"app.root/db"
+)
+
+// Reason recorded in the audit log for auto-hidden near-duplicates.
+const reasonNearDuplicate = "NEAR_DUPLICATE"
+
+/*
+Near-duplicate check against recent listings (any source, hidden
+included) by SimHash Hamming distance. Returns the ID of the newest
+match. Rows without a fingerprint never match; excludeID (an edited
+listing) never matches itself.
+*/
+func nearDuplicate(ctx context.Context, store *db.Store, dedup config.Dedup, simhash sql.NullInt64, excludeID int64) (int64, bool, error) {
+	if !dedup.Enable || !simhash.Valid {
+		return 0, false, nil
+	}
+
+	id, err := store.FindNearDuplicateListing(ctx, db.FindNearDuplicateListingParams{
+		Since:       time.Now().Add(-dedup.Window()),
+		Simhash:     simhash.Int64,
+		MaxDistance: int32(dedup.MaxDistance),
+		ExcludeID:   excludeID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return id, true, nil
+}
@@ -0,0 +1,101 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// DeleteHandler serves DELETE /api/listings/{id} with body
+// {"token": "..."}: the author retracts their own listing.
+//
+// The listing is hidden rather than removed, so the retention job
+// purges it later like any other hidden row. The token is burned.
+type DeleteHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+}
+
+// Audit log entry for a retraction (see admin.Action*).
+const (
+	authorActor   = "author"
+	actionRetract = "retract"
+)
+
+type deleteListingRequest struct {
+	Token string `json:"token"`
+}
+
+func (h *DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Forbidden(w, "RATE_LIMITED", "request blocked")
+			return
+		}
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing id")
+		return
+	}
+
+	var req deleteListingRequest
+	if err := httpjson.Decode(r, &req); err != nil {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid json body")
+		return
+	}
+
+	if strings.TrimSpace(req.Token) == "" {
+		httpjson.BadRequest(w, "INVALID_INPUT", "missing token")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	store := db.NewStore(h.DB)
+
+	var retracted bool
+
+	err = store.ExecTx(ctx, func(tx *db.Store) error {
+		n, err := tx.RetractListing(ctx, db.RetractListingParams{
+			ID:              id,
+			DeleteTokenHash: hashDeleteToken(req.Token),
+		})
+		if err != nil || n == 0 {
+			return err
+		}
+		retracted = true
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			ListingID: sql.NullInt64{Int64: id, Valid: true},
+			Actor:     authorActor,
+			Action:    actionRetract,
+		})
+	})
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	// Unknown id, wrong token and already retracted look the same.
+	if !retracted {
+		httpjson.NotFound(w, "NOT_FOUND", "listing not found or token invalid")
+		return
+	}
+
+	httpjson.WriteNoContent(w)
+}
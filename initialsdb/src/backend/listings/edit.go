@@ -0,0 +1,232 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"app.root/config"
+	"app.root/content"
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// EditHandler serves PATCH /api/listings/{id} with body
+// {"token": "...", "text": "..."}: the author replaces the body
+// within cfg.Edit.Window() of posting. The previous body is kept
+// in listing_revisions; body_tsv follows the new body. The new body
+// goes through the same policy, near-duplicate and pre-moderation
+// checks as a new post.
+type EditHandler struct {
+	DB      *sql.DB
+	Cfg     *config.Config
+	Guards  []guards.Guard
+	Content *content.Filter // nil = no content policy
+}
+
+// Audit log entry for an author edit (see admin.Action*).
+const actionEdit = "edit"
+
+type editListingRequest struct {
+	Token string `json:"token"`
+	Text  string `json:"text"`
+}
+
+type editListingResponse struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	EditedAt  time.Time `json:"edited_at"`
+	IsHidden  bool      `json:"is_hidden"`
+	IsPending bool      `json:"is_pending"`
+}
+
+var (
+	errTooManyEdits  = errors.New("too many edits")
+	errDuplicateEdit = errors.New("near-duplicate edit")
+)
+
+func (h *EditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Forbidden(w, "RATE_LIMITED", "request blocked")
+			return
+		}
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing id")
+		return
+	}
+
+	var req editListingRequest
+	if err := httpjson.Decode(r, &req); err != nil {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid json body")
+		return
+	}
+
+	if strings.TrimSpace(req.Token) == "" {
+		httpjson.BadRequest(w, "INVALID_INPUT", "missing token")
+		return
+	}
+
+	body := strings.TrimSpace(req.Text)
+	if body == "" {
+		httpjson.BadRequest(w, "INVALID_INPUT", "empty body")
+		return
+	}
+
+	// Same policy as create, so an edit cannot smuggle in what a
+	// new post would not get through.
+	verdict := h.Content.Evaluate(body)
+	if verdict.Action == content.Reject {
+		httpjson.BadRequest(w, "CONTENT_REJECTED", verdict.Reason)
+		return
+	}
+
+	var simhash sql.NullInt64
+	if fp, ok := content.SimHash(body); ok {
+		simhash = sql.NullInt64{Int64: fp, Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	store := db.NewStore(h.DB)
+
+	var (
+		updated  db.UpdateListingBodyRow
+		shadowed bool
+	)
+
+	err = store.ExecTx(ctx, func(tx *db.Store) error {
+		cur, err := tx.GetEditableListing(ctx, db.GetEditableListingParams{
+			ID:              id,
+			DeleteTokenHash: hashDeleteToken(req.Token),
+			CreatedAfter:    time.Now().Add(-h.Cfg.Edit.Window()),
+		})
+		if err != nil {
+			return err
+		}
+
+		if max := h.Cfg.Edit.MaxRevisions; max > 0 {
+			n, err := tx.CountListingRevisions(ctx, id)
+			if err != nil {
+				return err
+			}
+			if n >= int64(max) {
+				return errTooManyEdits
+			}
+		}
+
+		shadowed = cur.IsShadowed
+
+		dupID, dup, err := nearDuplicate(ctx, tx, h.Cfg.Dedup, simhash, id)
+		if err != nil {
+			return err
+		}
+		if dup && h.Cfg.Dedup.Action != "hide" {
+			return errDuplicateEdit
+		}
+
+		// As on create: a shadowed row stays as it is, the author
+		// keeps seeing it.
+		hide := dup && !shadowed
+
+		var (
+			hold   bool
+			reason string
+		)
+		if hide {
+			reason = reasonNearDuplicate + " #" + strconv.FormatInt(dupID, 10)
+		} else {
+			hold, reason = h.holdEdit(verdict, body, shadowed)
+		}
+
+		if err := tx.CreateListingRevision(ctx, db.CreateListingRevisionParams{
+			ListingID: id,
+			Body:      cur.Body,
+		}); err != nil {
+			return err
+		}
+
+		updated, err = tx.UpdateListingBody(ctx, db.UpdateListingBodyParams{
+			Body:    body,
+			Simhash: simhash,
+			Hold:    hold,
+			Hide:    hide,
+			ID:      id,
+		})
+		if err != nil {
+			return err
+		}
+
+		action := actionEdit
+		switch {
+		case hide:
+			action = actionAutoHide
+		case hold:
+			action = actionHold
+		}
+
+		return tx.CreateModerationEvent(ctx, db.CreateModerationEventParams{
+			ListingID: sql.NullInt64{Int64: id, Valid: true},
+			Actor:     authorActor,
+			Action:    action,
+			Reason:    reason,
+		})
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		httpjson.NotFound(w, "NOT_FOUND", "listing not found, token invalid or edit window closed")
+		return
+	}
+	if errors.Is(err, errTooManyEdits) {
+		httpjson.Conflict(w, "TOO_MANY_EDITS", "edit limit reached for this listing")
+		return
+	}
+	if errors.Is(err, errDuplicateEdit) {
+		httpjson.Conflict(w, "DUPLICATE_CONTENT", "a very similar listing was posted recently")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	httpjson.WriteOK(w, editListingResponse{
+		ID:        updated.ID,
+		Body:      updated.Body,
+		CreatedAt: updated.CreatedAt,
+		EditedAt:  updated.EditedAt.Time,
+		IsHidden:  updated.IsHidden && !shadowed,
+		IsPending: updated.IsPending,
+	})
+}
+
+// holdEdit sends an edited listing back to the review queue when the
+// policy quarantines the new body or a pre-moderation heuristic holds
+// it: the approval was for the old text. Shadowed rows are never
+// queued.
+func (h *EditHandler) holdEdit(verdict content.Verdict, body string, shadowed bool) (bool, string) {
+	if shadowed {
+		return false, ""
+	}
+
+	if verdict.Action == content.Quarantine {
+		return true, verdict.Reason
+	}
+
+	return holdBody(h.Cfg.PreModeration, body)
+}
@@ -0,0 +1,40 @@
+package listings
+
+import (
+	"database/sql"
+	"math"
+	"time"
+)
+
+/*
+Per-listing expiry: the author may ask for a lifetime on create,
+clamped to cfg.Expiry.MaxLifetime(). Reads drop expired rows at
+once; jobs.Expiry hides them later so retention can purge them.
+*/
+
+// listingExpiry turns the requested lifetime into expires_at.
+// Zero means no expiry; negative (or absurdly large) is invalid.
+func (h *CreateHandler) listingExpiry(secs int64, now time.Time) (sql.NullTime, bool) {
+	if secs < 0 || secs > math.MaxInt64/int64(time.Second) {
+		return sql.NullTime{}, false
+	}
+
+	exp := h.Cfg.Expiry
+	if !exp.Enable || secs == 0 {
+		return sql.NullTime{}, true
+	}
+
+	lifetime := time.Duration(secs) * time.Second
+	if max := exp.MaxLifetime(); max > 0 && lifetime > max {
+		lifetime = max
+	}
+
+	return sql.NullTime{Time: now.Add(lifetime), Valid: true}, true
+}
+
+func expiresAt(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,123 @@
+package listings
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+	_ "time/tzdata" // tz= must work without zoneinfo in the image
+)
+
+/*
+Search filters, all optional and combined with AND:
+
+	since, until   created_at in [since, until)
+	tz             IANA zone for since/until without an offset (default UTC)
+	has_links      true | false
+	min_length     body_length >= n
+	max_length     body_length <= n
+	max_links      link_count <= n
+
+since/until take RFC 3339 ("2024-05-01T10:00:00+02:00"), a local
+time ("2024-05-01T10:00:00") or a date ("2024-05-01"). A date for
+until includes that whole day. Filters apply to every page the same
+way; the client repeats them with the cursor.
+*/
+
+type searchFilter struct {
+	since     sql.NullTime
+	until     sql.NullTime
+	hasLinks  sql.NullBool
+	minLength sql.NullInt32
+	maxLength sql.NullInt32
+	maxLinks  sql.NullInt32
+}
+
+const (
+	dateLayout  = "2006-01-02"
+	localLayout = "2006-01-02T15:04:05"
+)
+
+func parseSearchFilter(v url.Values) (searchFilter, error) {
+	var f searchFilter
+
+	loc := time.UTC
+	if tz := v.Get("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return f, errors.New("invalid tz")
+		}
+		loc = l
+	}
+
+	var err error
+	if f.since, err = parseBound(v.Get("since"), loc, false); err != nil {
+		return f, errors.New("invalid since")
+	}
+	if f.until, err = parseBound(v.Get("until"), loc, true); err != nil {
+		return f, errors.New("invalid until")
+	}
+	if f.since.Valid && f.until.Valid && !f.since.Time.Before(f.until.Time) {
+		return f, errors.New("since must be before until")
+	}
+
+	if s := v.Get("has_links"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return f, errors.New("has_links must be true or false")
+		}
+		f.hasLinks = sql.NullBool{Bool: b, Valid: true}
+	}
+
+	if f.minLength, err = parseCount(v.Get("min_length")); err != nil {
+		return f, errors.New("invalid min_length")
+	}
+	if f.maxLength, err = parseCount(v.Get("max_length")); err != nil {
+		return f, errors.New("invalid max_length")
+	}
+	if f.minLength.Valid && f.maxLength.Valid && f.minLength.Int32 > f.maxLength.Int32 {
+		return f, errors.New("min_length must not exceed max_length")
+	}
+	if f.maxLinks, err = parseCount(v.Get("max_links")); err != nil {
+		return f, errors.New("invalid max_links")
+	}
+
+	return f, nil
+}
+
+// parseBound reads one end of the created_at range, in UTC. A bare
+// date is midnight in loc; as an upper bound it means the next one.
+func parseBound(s string, loc *time.Location, upper bool) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t, err = time.ParseInLocation(localLayout, s, loc)
+	}
+	if err != nil {
+		t, err = time.ParseInLocation(dateLayout, s, loc)
+		if err == nil && upper {
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+func parseCount(s string) (sql.NullInt32, error) {
+	if s == "" {
+		return sql.NullInt32{}, nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || n < 0 {
+		return sql.NullInt32{}, errors.New("not a count")
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}, nil
+}
@@ -0,0 +1,124 @@
+package listings
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"app.root/cursor"
+	"app.root/db"
+)
+
+/*
+mode=fuzzy: pg_trgm over the raw body (idx_listings_visible_body_trgm).
+Finds what full-text search cannot: misspellings and partial words
+via word_similarity, and literal substrings like phone numbers or
+codes via ILIKE. Ordered by similarity; the cursor carries the score
+of the last row, like sort=relevance. syntax and lang do not apply.
+*/
+
+// Trigrams need three characters to say anything useful.
+const minFuzzyRunes = 3
+
+func fuzzyUsable(q string) bool {
+	return utf8.RuneCountInString(q) >= minFuzzyRunes
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// substringPattern is an ILIKE pattern matching q literally anywhere.
+func substringPattern(q string) string {
+	return "%" + likeEscaper.Replace(q) + "%"
+}
+
+// searchFuzzy returns one page and the cursor for the next one.
+// ok is false when sq.after is not a scored cursor.
+func (h *SearchHandler) searchFuzzy(ctx context.Context, store *db.Store, sq searchQuery) (rows []listingResult, next string, ok bool, err error) {
+	var scored []rankedResult
+
+	if sq.after == "" {
+		res, err := store.SearchListingsFuzzyFirstPage(
+			ctx,
+			db.SearchListingsFuzzyFirstPageParams{
+				Q:            sq.q,
+				Pattern:      substringPattern(sq.q),
+				Since:        sq.filter.since,
+				Until:        sq.filter.until,
+				HasLinks:     sq.filter.hasLinks,
+				MinLength:    sq.filter.minLength,
+				MaxLength:    sq.filter.maxLength,
+				MaxLinks:     sq.filter.maxLinks,
+				RowLimit:     sq.limit,
+				ViewerIpHash: sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, "", true, err
+		}
+
+		scored = make([]rankedResult, 0, len(res))
+		for _, r := range res {
+			scored = append(scored, rankedResult{
+				listingResult: listingResult{
+					ID:        r.ID,
+					Body:      r.Body,
+					CreatedAt: r.CreatedAt,
+					ExpiresAt: expiresAt(r.ExpiresAt),
+				},
+				rank: r.Score,
+			})
+		}
+	} else {
+		score, createdAt, id, valid := cursor.DecodeScored(sq.after)
+		if !valid {
+			return nil, "", false, nil
+		}
+
+		res, err := store.SearchListingsFuzzyAfterCursor(
+			ctx,
+			db.SearchListingsFuzzyAfterCursorParams{
+				Q:            sq.q,
+				Pattern:      substringPattern(sq.q),
+				Score:        score,
+				CreatedAt:    createdAt,
+				ID:           id,
+				Since:        sq.filter.since,
+				Until:        sq.filter.until,
+				HasLinks:     sq.filter.hasLinks,
+				MinLength:    sq.filter.minLength,
+				MaxLength:    sq.filter.maxLength,
+				MaxLinks:     sq.filter.maxLinks,
+				RowLimit:     sq.limit,
+				ViewerIpHash: sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, "", true, err
+		}
+
+		scored = make([]rankedResult, 0, len(res))
+		for _, r := range res {
+			scored = append(scored, rankedResult{
+				listingResult: listingResult{
+					ID:        r.ID,
+					Body:      r.Body,
+					CreatedAt: r.CreatedAt,
+					ExpiresAt: expiresAt(r.ExpiresAt),
+				},
+				rank: r.Score,
+			})
+		}
+	}
+
+	rows = make([]listingResult, 0, len(scored))
+	for _, r := range scored {
+		rows = append(rows, r.listingResult)
+	}
+
+	if len(scored) == int(sq.limit) {
+		last := scored[len(scored)-1]
+		next = cursor.EncodeScored(last.rank, last.CreatedAt, last.ID)
+	}
+
+	return rows, next, true, nil
+}
@@ -0,0 +1,78 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// GetHandler serves GET /api/listings/{id}. Hidden, pending, expired
+// and unknown ids all answer 404.
+type GetHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+	Bans   *guards.BanGuard // shadow bans; nil = none
+}
+
+type listingResponse struct {
+	ID        int64      `json:"id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (h *GetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Forbidden(w, "RATE_LIMITED", "request blocked")
+			return
+		}
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	row, err := db.NewStore(h.DB).GetVisibleListing(ctx, db.GetVisibleListingParams{
+		ID:           id,
+		ViewerIpHash: h.Bans.ShadowBannedViewer(r),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpjson.NotFound(w, "NOT_FOUND", "listing not found")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	resp := listingResponse{
+		ID:        row.ID,
+		Body:      row.Body,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: expiresAt(row.ExpiresAt),
+	}
+	if row.EditedAt.Valid {
+		resp.EditedAt = &row.EditedAt.Time
+	}
+
+	httpjson.WriteOK(w, resp)
+}
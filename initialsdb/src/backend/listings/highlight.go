@@ -0,0 +1,50 @@
+package listings
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+highlight=1: each full-text result gets a snippet built by ts_headline.
+
+ts_headline does not escape anything, so it marks matches with two
+control characters; the snippet is HTML-escaped here and only then
+are those replaced by the configured markers. The snippet is safe to
+insert as HTML as long as the markers are. For bodies longer than
+LongBody the body itself is dropped and only the snippet is sent.
+Fuzzy results have no snippet.
+*/
+
+type Highlight struct {
+	StartSel string // e.g. "<mark>"
+	StopSel  string // e.g. "</mark>"
+	LongBody int    // runes; longer bodies are replaced by the snippet
+}
+
+const (
+	headlineStart = "\x01"
+	headlineStop  = "\x02"
+)
+
+// headlineOptions is passed to ts_headline as its options string.
+const headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", ` +
+	`MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+// apply turns raw ts_headline output into safe snippets, in place.
+func (hl Highlight) apply(rows []listingResult) {
+	markers := strings.NewReplacer(headlineStart, hl.StartSel, headlineStop, hl.StopSel)
+
+	for i := range rows {
+		if rows[i].Snippet == "" {
+			continue
+		}
+
+		rows[i].Snippet = markers.Replace(html.EscapeString(rows[i].Snippet))
+
+		if hl.LongBody > 0 && utf8.RuneCountInString(rows[i].Body) > hl.LongBody {
+			rows[i].Body = ""
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// HistoryHandler serves GET /api/listings/{id}/revisions: the current
+// body plus the bodies it replaced, newest first.
+type HistoryHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+	Bans   *guards.BanGuard // shadow bans; nil = none
+}
+
+const maxRevisionsShown = 50
+
+type revisionResult struct {
+	Body       string    `json:"body"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type historyResponse struct {
+	ID        int64            `json:"id"`
+	Body      string           `json:"body"`
+	CreatedAt time.Time        `json:"created_at"`
+	EditedAt  *time.Time       `json:"edited_at,omitempty"`
+	Revisions []revisionResult `json:"revisions"`
+}
+
+func (h *HistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Forbidden(w, "RATE_LIMITED", "request blocked")
+			return
+		}
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid listing id")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	store := db.NewStore(h.DB)
+
+	cur, err := store.GetVisibleListing(ctx, db.GetVisibleListingParams{
+		ID:           id,
+		ViewerIpHash: h.Bans.ShadowBannedViewer(r),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpjson.NotFound(w, "NOT_FOUND", "listing not found")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	revs, err := store.ListListingRevisions(ctx, db.ListListingRevisionsParams{
+		ListingID: id,
+		Limit:     maxRevisionsShown,
+	})
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	resp := historyResponse{
+		ID:        cur.ID,
+		Body:      cur.Body,
+		CreatedAt: cur.CreatedAt,
+		Revisions: make([]revisionResult, 0, len(revs)),
+	}
+	if cur.EditedAt.Valid {
+		resp.EditedAt = &cur.EditedAt.Time
+	}
+
+	for _, rev := range revs {
+		resp.Revisions = append(resp.Revisions, revisionResult{
+			Body:       rev.Body,
+			ReplacedAt: rev.CreatedAt,
+		})
+	}
+
+	httpjson.WriteOK(w, resp)
+}
@@ -0,0 +1,36 @@
+package listings
+
+import (
+	"net/http"
+
+	"app.root/httpjson"
+)
+
+// ItemHandler routes /api/listings/{id} by method. A method-qualified
+// mux pattern would conflict with /api/listings/search and friends,
+// so the dispatch happens here. Nil handlers answer 405.
+type ItemHandler struct {
+	Get    http.Handler // GET
+	Edit   http.Handler // PATCH
+	Delete http.Handler // DELETE
+}
+
+func (h *ItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var next http.Handler
+
+	switch r.Method {
+	case http.MethodGet:
+		next = h.Get
+	case http.MethodPatch:
+		next = h.Edit
+	case http.MethodDelete:
+		next = h.Delete
+	}
+
+	if next == nil {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
@@ -0,0 +1,163 @@
+package listings
+
+import (
+	"context"
+	"time"
+
+	"app.root/db"
+)
+
+/*
+Date order is a keyset over (created_at, id) that can be read either
+way: newest first (the default), or oldest first with order=asc.
+
+Cursors carry the direction they were issued for. next_cursor goes on
+in the requested order; prev_cursor walks back, which is the opposite
+scan with the page reversed before it is returned. Relevance and
+fuzzy orders page forward only.
+*/
+
+// dateScan is one page of the (created_at, id) keyset.
+type dateScan struct {
+	asc       bool // read oldest first
+	from      bool // start past (createdAt, id); else the first page
+	createdAt time.Time
+	id        int64
+}
+
+func listingsByDate(ctx context.Context, store *db.Store, sq searchQuery, scan dateScan) ([]listingResult, error) {
+	rows := make([]listingResult, 0, sq.limit)
+
+	switch {
+	case !scan.from && !scan.asc:
+		res, err := store.SearchListingsFirstPage(
+			ctx,
+			db.SearchListingsFirstPageParams{
+				Q:               sq.q,
+				Web:             sq.web,
+				Lang:            sq.lang,
+				HeadlineOptions: sq.headlineOptions(),
+				Since:           sq.filter.since,
+				Until:           sq.filter.until,
+				HasLinks:        sq.filter.hasLinks,
+				MinLength:       sq.filter.minLength,
+				MaxLength:       sq.filter.maxLength,
+				MaxLinks:        sq.filter.maxLinks,
+				RowLimit:        sq.limit,
+				ViewerIpHash:    sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, listingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				Snippet:   r.Snippet.String,
+				CreatedAt: r.CreatedAt,
+				ExpiresAt: expiresAt(r.ExpiresAt),
+			})
+		}
+
+	case !scan.from && scan.asc:
+		res, err := store.SearchListingsAscFirstPage(
+			ctx,
+			db.SearchListingsAscFirstPageParams{
+				Q:               sq.q,
+				Web:             sq.web,
+				Lang:            sq.lang,
+				HeadlineOptions: sq.headlineOptions(),
+				Since:           sq.filter.since,
+				Until:           sq.filter.until,
+				HasLinks:        sq.filter.hasLinks,
+				MinLength:       sq.filter.minLength,
+				MaxLength:       sq.filter.maxLength,
+				MaxLinks:        sq.filter.maxLinks,
+				RowLimit:        sq.limit,
+				ViewerIpHash:    sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, listingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				Snippet:   r.Snippet.String,
+				CreatedAt: r.CreatedAt,
+				ExpiresAt: expiresAt(r.ExpiresAt),
+			})
+		}
+
+	case !scan.asc:
+		res, err := store.SearchListingsAfterCursor(
+			ctx,
+			db.SearchListingsAfterCursorParams{
+				Q:               sq.q,
+				Web:             sq.web,
+				Lang:            sq.lang,
+				HeadlineOptions: sq.headlineOptions(),
+				CreatedAt:       scan.createdAt,
+				ID:              scan.id,
+				Since:           sq.filter.since,
+				Until:           sq.filter.until,
+				HasLinks:        sq.filter.hasLinks,
+				MinLength:       sq.filter.minLength,
+				MaxLength:       sq.filter.maxLength,
+				MaxLinks:        sq.filter.maxLinks,
+				RowLimit:        sq.limit,
+				ViewerIpHash:    sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, listingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				Snippet:   r.Snippet.String,
+				CreatedAt: r.CreatedAt,
+				ExpiresAt: expiresAt(r.ExpiresAt),
+			})
+		}
+
+	default:
+		res, err := store.SearchListingsAscAfterCursor(
+			ctx,
+			db.SearchListingsAscAfterCursorParams{
+				Q:               sq.q,
+				Web:             sq.web,
+				Lang:            sq.lang,
+				HeadlineOptions: sq.headlineOptions(),
+				CreatedAt:       scan.createdAt,
+				ID:              scan.id,
+				Since:           sq.filter.since,
+				Until:           sq.filter.until,
+				HasLinks:        sq.filter.hasLinks,
+				MinLength:       sq.filter.minLength,
+				MaxLength:       sq.filter.maxLength,
+				MaxLinks:        sq.filter.maxLinks,
+				RowLimit:        sq.limit,
+				ViewerIpHash:    sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range res {
+			rows = append(rows, listingResult{
+				ID:        r.ID,
+				Body:      r.Body,
+				Snippet:   r.Snippet.String,
+				CreatedAt: r.CreatedAt,
+				ExpiresAt: expiresAt(r.ExpiresAt),
+			})
+		}
+
+	}
+
+	return rows, nil
+}
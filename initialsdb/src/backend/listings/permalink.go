@@ -0,0 +1,64 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/spa"
+)
+
+// Permalink resolves /l/{id} for the SPA, so a shared link previews
+// with the listing's own title and description. Same visibility as
+// GET /api/listings/{id}.
+type Permalink struct {
+	DB   *sql.DB
+	Bans *guards.BanGuard // shadow bans; nil = none
+}
+
+const (
+	permalinkTitleRunes = 70
+	permalinkDescRunes  = 200
+)
+
+func (p *Permalink) Page(r *http.Request) (spa.Page, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return spa.Page{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	row, err := db.NewStore(p.DB).GetVisibleListing(ctx, db.GetVisibleListingParams{
+		ID:           id,
+		ViewerIpHash: p.Bans.ShadowBannedViewer(r),
+	})
+	if err != nil {
+		return spa.Page{}, false
+	}
+
+	// Title: the first line; description: the whole body, one line.
+	title, _, _ := strings.Cut(strings.TrimSpace(row.Body), "\n")
+	desc := strings.Join(strings.Fields(row.Body), " ")
+
+	return spa.Page{
+		Title:       truncateRunes(strings.TrimSpace(title), permalinkTitleRunes),
+		Description: truncateRunes(desc, permalinkDescRunes),
+	}, true
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,65 @@
+package listings
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"app.root/config"
+	"app.root/content"
+	"app.root/db"
+)
+
+// Reasons recorded in the audit log when a post is held for review.
+const (
+	reasonHasLinks  = "HAS_LINKS"
+	reasonFirstPost = "FIRST_POST"
+	reasonLongBody  = "LONG_BODY"
+)
+
+/*
+Pre-moderation heuristics: a post that trips one of them is stored
+with is_pending = TRUE and stays out of search and count until a
+moderator approves it (admin.QueueHandler).
+
+Returns the reason code of the first heuristic that fired.
+*/
+func (h *CreateHandler) holdForReview(ctx context.Context, store *db.Store, body string, ipHash []byte) (bool, string, error) {
+	premod := h.Cfg.PreModeration
+	if !premod.Enable {
+		return false, "", nil
+	}
+
+	if hold, reason := holdBody(premod, body); hold {
+		return true, reason, nil
+	}
+
+	if premod.HoldFirstPost {
+		seen, err := store.ListingExistsByIP(ctx, ipHash)
+		if err != nil {
+			return false, "", err
+		}
+		if !seen {
+			return true, reasonFirstPost, nil
+		}
+	}
+
+	return false, "", nil
+}
+
+// holdBody runs the heuristics that only look at the text. Edits go
+// through these too; FIRST_POST does not apply to them.
+func holdBody(premod config.PreModeration, body string) (bool, string) {
+	if !premod.Enable {
+		return false, ""
+	}
+
+	if premod.HoldLinks && content.HasLinks(body) {
+		return true, reasonHasLinks
+	}
+
+	if premod.HoldMinLength > 0 && utf8.RuneCountInString(body) >= premod.HoldMinLength {
+		return true, reasonLongBody
+	}
+
+	return false, ""
+}
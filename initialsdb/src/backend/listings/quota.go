@@ -0,0 +1,39 @@
+package listings
+
+import (
+	"context"
+
+	"app.root/db"
+)
+
+/*
+Posting quota per ip_hash, counted from the listings table itself.
+
+Unlike IPRateGuard (in-memory, per process) it survives restarts and
+holds across several app instances. Check-then-insert is not atomic,
+so concurrent posts from one source may overshoot by a few; fine for
+a quota.
+
+Hidden listings count too: a moderated spammer does not get a refill.
+*/
+func (h *CreateHandler) quotaExceeded(ctx context.Context, store *db.Store, ipHash []byte) (bool, error) {
+	quota := h.Cfg.PostQuota
+	if !quota.Enable {
+		return false, nil
+	}
+
+	n, err := store.CountRecentListingsByIP(ctx, ipHash)
+	if err != nil {
+		return false, err
+	}
+
+	if quota.MaxPerHour > 0 && n.LastHour >= int64(quota.MaxPerHour) {
+		return true, nil
+	}
+
+	if quota.MaxPerDay > 0 && n.LastDay >= int64(quota.MaxPerDay) {
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,119 @@
+package listings
+
+import (
+	"context"
+
+	"app.root/cursor"
+	"app.root/db"
+)
+
+/*
+sort=relevance: ts_rank_cd over body_tsv, best match first. The
+cursor carries the rank of the last row next to (created_at, id),
+which break ties.
+*/
+
+// rankedResult keeps the sort score (rank or similarity) next to the
+// row until the cursor is built; it is not part of the response.
+type rankedResult struct {
+	listingResult
+	rank float32
+}
+
+// searchByRank returns one page and the cursor for the next one.
+// ok is false when sq.after is not a relevance cursor.
+func (h *SearchHandler) searchByRank(ctx context.Context, store *db.Store, sq searchQuery) (rows []listingResult, next string, ok bool, err error) {
+	var ranked []rankedResult
+
+	if sq.after == "" {
+		res, err := store.SearchListingsByRankFirstPage(
+			ctx,
+			db.SearchListingsByRankFirstPageParams{
+				Q:               sq.q,
+				Web:             sq.web,
+				Lang:            sq.lang,
+				HeadlineOptions: sq.headlineOptions(),
+				Since:           sq.filter.since,
+				Until:           sq.filter.until,
+				HasLinks:        sq.filter.hasLinks,
+				MinLength:       sq.filter.minLength,
+				MaxLength:       sq.filter.maxLength,
+				MaxLinks:        sq.filter.maxLinks,
+				RowLimit:        sq.limit,
+				ViewerIpHash:    sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, "", true, err
+		}
+
+		ranked = make([]rankedResult, 0, len(res))
+		for _, r := range res {
+			ranked = append(ranked, rankedResult{
+				listingResult: listingResult{
+					ID:        r.ID,
+					Body:      r.Body,
+					Snippet:   r.Snippet.String,
+					CreatedAt: r.CreatedAt,
+					ExpiresAt: expiresAt(r.ExpiresAt),
+				},
+				rank: r.Rank,
+			})
+		}
+	} else {
+		rank, createdAt, id, valid := cursor.DecodeScored(sq.after)
+		if !valid {
+			return nil, "", false, nil
+		}
+
+		res, err := store.SearchListingsByRankAfterCursor(
+			ctx,
+			db.SearchListingsByRankAfterCursorParams{
+				Q:               sq.q,
+				Web:             sq.web,
+				Lang:            sq.lang,
+				HeadlineOptions: sq.headlineOptions(),
+				Rank:            rank,
+				CreatedAt:       createdAt,
+				ID:              id,
+				Since:           sq.filter.since,
+				Until:           sq.filter.until,
+				HasLinks:        sq.filter.hasLinks,
+				MinLength:       sq.filter.minLength,
+				MaxLength:       sq.filter.maxLength,
+				MaxLinks:        sq.filter.maxLinks,
+				RowLimit:        sq.limit,
+				ViewerIpHash:    sq.viewer,
+			},
+		)
+		if err != nil {
+			return nil, "", true, err
+		}
+
+		ranked = make([]rankedResult, 0, len(res))
+		for _, r := range res {
+			ranked = append(ranked, rankedResult{
+				listingResult: listingResult{
+					ID:        r.ID,
+					Body:      r.Body,
+					Snippet:   r.Snippet.String,
+					CreatedAt: r.CreatedAt,
+					ExpiresAt: expiresAt(r.ExpiresAt),
+				},
+				rank: r.Rank,
+			})
+		}
+	}
+
+	rows = make([]listingResult, 0, len(ranked))
+	for _, r := range ranked {
+		rows = append(rows, r.listingResult)
+	}
+
+	if len(ranked) == int(sq.limit) {
+		last := ranked[len(ranked)-1]
+		next = cursor.EncodeScored(last.rank, last.CreatedAt, last.ID)
+	}
+
+	return rows, next, true, nil
+}
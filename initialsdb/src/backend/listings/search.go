@@ -3,31 +3,76 @@ package listings
 import (
 	"context"
 	"database/sql"
-	"encoding/base64"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"app.root/content"
+	"app.root/cursor"
 	"app.root/db"
 	"app.root/guards"
 	"app.root/httpjson"
 )
 
 type SearchHandler struct {
-	DB     *sql.DB
-	Guards []guards.Guard
+	DB            *sql.DB
+	Guards        []guards.Guard
+	Bans          *guards.BanGuard // shadow bans; nil = none
+	FuzzyFallback bool             // retry a first page with no FTS hits as mode=fuzzy
+	Highlight     Highlight        // snippet markers for highlight=1
+	Cursors       *cursor.Signer   // seals next_cursor / prev_cursor
+	Totals        Totals           // limits for with_total=1
 }
 
+// Search modes reported in searchResponse.Mode.
+const (
+	modeFTS   = "fts"
+	modeFuzzy = "fuzzy"
+)
+
 type listingResult struct {
-	ID        int64     `json:"id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64      `json:"id"`
+	Body      string     `json:"body,omitempty"` // empty: long body, see Snippet
+	Snippet   string     `json:"snippet,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// searchQuery is the parsed request shared by every search path.
+type searchQuery struct {
+	q     string
+	web   bool   // syntax=web: websearch_to_tsquery
+	lang  string // text search configuration of q
+	asc   bool   // order=asc: oldest first (date order only)
+	limit int32
+	after string
+
+	filter    searchFilter
+	highlight bool // highlight=1: ts_headline snippets
+
+	// Shadow bans: a shadow-banned source's listings are stored hidden
+	// (is_shadowed), yet its own searches must still show them. Every
+	// search query also matches the shadowed rows of viewer (nil for
+	// everyone but such a source); they never reach anyone else.
+	viewer []byte
+}
+
+func (sq searchQuery) headlineOptions() string {
+	if !sq.highlight {
+		return ""
+	}
+	return headlineOptions
 }
 
 type searchResponse struct {
 	Items      []listingResult `json:"items"`
 	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"` // date order only
+	Total      *searchTotal    `json:"total,omitempty"`       // with_total=1, full-text only
+	Mode       string          `json:"mode"`                  // pass back as mode= with next_cursor
 }
 
 func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -47,109 +92,278 @@ func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	sq := searchQuery{
+		q:      strings.TrimSpace(r.URL.Query().Get("q")),
+		lang:   content.LangSimple,
+		limit:  30,
+		after:  r.URL.Query().Get("cursor"),
+		viewer: h.Bans.ShadowBannedViewer(r),
+	}
+
+	if l := r.URL.Query().Get("lang"); l != "" {
+		cfg, ok := content.TextSearchConfig(l)
+		if !ok {
+			httpjson.BadRequest(w, "INVALID_INPUT", "unsupported lang")
+			return
+		}
+		sq.lang = cfg
+	}
 
-	limit := int32(30)
 	if l := r.URL.Query().Get("limit"); l != "" {
 		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
-			limit = int32(v)
+			sq.limit = int32(v)
 		}
 	}
 
-	cursor := r.URL.Query().Get("cursor")
-	store := db.NewStore(h.DB)
+	filter, err := parseSearchFilter(r.URL.Query())
+	if err != nil {
+		httpjson.BadRequest(w, "INVALID_INPUT", err.Error())
+		return
+	}
+	sq.filter = filter
+
+	switch r.URL.Query().Get("syntax") {
+	case "", "plain":
+	case "web":
+		if err := validateWebQuery(sq.q); err != nil {
+			httpjson.BadRequest(w, "INVALID_INPUT", err.Error())
+			return
+		}
+		sq.web = true
+	default:
+		httpjson.BadRequest(w, "INVALID_INPUT", "syntax must be plain or web")
+		return
+	}
 
-	var rows []listingResult
+	switch r.URL.Query().Get("highlight") {
+	case "", "0":
+	case "1":
+		// Nothing to mark without a query.
+		sq.highlight = sq.q != ""
+	default:
+		httpjson.BadRequest(w, "INVALID_INPUT", "highlight must be 0 or 1")
+		return
+	}
 
-	if cursor == "" {
-		res, err := store.SearchListingsFirstPage(
-			ctx,
-			db.SearchListingsFirstPageParams{
-				Column1: q,
-				Limit:   limit,
-			},
-		)
-		if err != nil {
-			httpjson.InternalError(w, "db error")
+	withTotal := false
+	switch r.URL.Query().Get("with_total") {
+	case "", "0":
+	case "1":
+		withTotal = true
+	default:
+		httpjson.BadRequest(w, "INVALID_INPUT", "with_total must be 0 or 1")
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = modeFTS
+	case modeFTS:
+	case modeFuzzy:
+		if !fuzzyUsable(sq.q) {
+			httpjson.BadRequest(w, "INVALID_INPUT", "mode=fuzzy requires q of at least 3 characters")
 			return
 		}
+	default:
+		httpjson.BadRequest(w, "INVALID_INPUT", "mode must be fts or fuzzy")
+		return
+	}
 
-		rows = make([]listingResult, 0, len(res))
-		for _, r := range res {
-			rows = append(rows, listingResult{
-				ID:        r.ID,
-				Body:      r.Body,
-				CreatedAt: r.CreatedAt,
-			})
+	sort := r.URL.Query().Get("sort")
+	switch sort {
+	case "", "date":
+	case "relevance":
+		if sq.q == "" {
+			httpjson.BadRequest(w, "INVALID_INPUT", "sort=relevance requires q")
+			return
 		}
-	} else {
-		createdAt, id, ok := decodeCursor(cursor)
-		if !ok {
+	default:
+		httpjson.BadRequest(w, "INVALID_INPUT", "sort must be date or relevance")
+		return
+	}
+
+	switch r.URL.Query().Get("order") {
+	case "", "desc":
+	case "asc":
+		if mode != modeFTS || sort == "relevance" {
+			httpjson.BadRequest(w, "INVALID_INPUT", "order=asc requires date order")
+			return
+		}
+		sq.asc = true
+	default:
+		httpjson.BadRequest(w, "INVALID_INPUT", "order must be asc or desc")
+		return
+	}
+
+	if sq.after != "" {
+		inner, err := h.Cursors.Open(sq.after, cursorScope(sq, mode, sort))
+		switch {
+		case errors.Is(err, cursor.ErrMismatch), errors.Is(err, cursor.ErrExpired):
+			httpjson.BadRequest(w, "STALE_CURSOR", err.Error())
+			return
+		case err != nil:
 			httpjson.BadRequest(w, "INVALID_INPUT", "invalid cursor")
 			return
 		}
+		sq.after = inner
+	}
+
+	store := db.NewStore(h.DB)
 
-		res, err := store.SearchListingsAfterCursor(
-			ctx,
-			db.SearchListingsAfterCursorParams{
-				Column1:   q,
-				CreatedAt: createdAt,
-				ID:        id,
-				Limit:     limit,
-			},
-		)
+	var (
+		rows []listingResult
+		next string
+		prev string
+		ok   bool
+	)
+
+	switch {
+	case mode == modeFuzzy:
+		rows, next, ok, err = h.searchFuzzy(ctx, store, sq)
+	case sort == "relevance":
+		rows, next, ok, err = h.searchByRank(ctx, store, sq)
+	default:
+		rows, next, prev, ok, err = h.searchByDate(ctx, store, sq)
+	}
+
+	if !ok {
+		httpjson.BadRequest(w, "INVALID_INPUT", "invalid cursor")
+		return
+	}
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	// Nothing matched as words: try again as fuzzy. Only on the first
+	// page; the client continues with mode=fuzzy. Fuzzy results have
+	// no oldest-first order, so order=asc does not fall back.
+	if mode == modeFTS && len(rows) == 0 && sq.after == "" && !sq.asc && h.FuzzyFallback && fuzzyUsable(sq.q) {
+		mode = modeFuzzy
+		rows, next, _, err = h.searchFuzzy(ctx, store, sq)
 		if err != nil {
 			httpjson.InternalError(w, "db error")
 			return
 		}
+	}
 
-		rows = make([]listingResult, 0, len(res))
-		for _, r := range res {
-			rows = append(rows, listingResult{
-				ID:        r.ID,
-				Body:      r.Body,
-				CreatedAt: r.CreatedAt,
-			})
-		}
+	if sq.highlight {
+		h.Highlight.apply(rows)
 	}
 
-	resp := searchResponse{
-		Items: rows,
+	var total *searchTotal
+	if withTotal && mode == modeFTS && sq.q != "" {
+		total = h.Totals.count(ctx, store, sq)
 	}
 
-	if len(rows) == int(limit) {
-		last := rows[len(rows)-1]
-		resp.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	scope := cursorScope(sq, mode, sort)
+
+	httpjson.WriteOK(w, searchResponse{
+		Items:      rows,
+		NextCursor: h.Cursors.Seal(next, scope),
+		PrevCursor: h.Cursors.Seal(prev, scope),
+		Mode:       mode,
+		Total:      total,
+	})
+}
+
+// cursorScope is everything that decides which rows a cursor walks
+// through, so a cursor only continues the query that issued it.
+// limit and highlight are left out: they do not change the order.
+func cursorScope(sq searchQuery, mode, sort string) string {
+	if sort == "" {
+		sort = "date"
 	}
 
-	httpjson.WriteOK(w, resp)
+	f := sq.filter
+	return strings.Join([]string{
+		sq.q,
+		strconv.FormatBool(sq.web),
+		sq.lang,
+		mode,
+		sort,
+		strconv.FormatBool(sq.asc),
+		scopeTime(f.since),
+		scopeTime(f.until),
+		scopeBool(f.hasLinks),
+		scopeInt(f.minLength),
+		scopeInt(f.maxLength),
+		scopeInt(f.maxLinks),
+	}, "\x00")
 }
 
-func encodeCursor(t time.Time, id int64) string {
-	payload := strconv.FormatInt(t.UnixNano(), 10) + ":" + strconv.FormatInt(id, 10)
-	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+func scopeTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return strconv.FormatInt(t.Time.UnixNano(), 10)
 }
 
-func decodeCursor(s string) (time.Time, int64, bool) {
-	b, err := base64.RawURLEncoding.DecodeString(s)
-	if err != nil {
-		return time.Time{}, 0, false
+func scopeBool(b sql.NullBool) string {
+	if !b.Valid {
+		return ""
 	}
+	return strconv.FormatBool(b.Bool)
+}
 
-	parts := strings.Split(string(b), ":")
-	if len(parts) != 2 {
-		return time.Time{}, 0, false
+func scopeInt(n sql.NullInt32) string {
+	if !n.Valid {
+		return ""
 	}
+	return strconv.FormatInt(int64(n.Int32), 10)
+}
 
-	ns, err := strconv.ParseInt(parts[0], 10, 64)
-	if err != nil {
-		return time.Time{}, 0, false
+// searchByDate orders by (created_at, id), newest first unless
+// sq.asc. ok is false when sq.after is not a date cursor.
+func (h *SearchHandler) searchByDate(ctx context.Context, store *db.Store, sq searchQuery) (rows []listingResult, next, prev string, ok bool, err error) {
+	scan := dateScan{asc: sq.asc}
+	dir := cursor.Next
+
+	if sq.after != "" {
+		scan.createdAt, scan.id, dir, ok = cursor.DecodePaged(sq.after)
+		if !ok {
+			return nil, "", "", false, nil
+		}
+		scan.from = true
 	}
 
-	id, err := strconv.ParseInt(parts[1], 10, 64)
+	// Walking back reads the other way; the page is flipped below.
+	if dir == cursor.Prev {
+		scan.asc = !scan.asc
+	}
+
+	rows, err = listingsByDate(ctx, store, sq, scan)
 	if err != nil {
-		return time.Time{}, 0, false
+		return nil, "", "", true, err
+	}
+
+	// More rows in the scanned direction only if the page is full;
+	// behind it there is at least the page the cursor came from.
+	more := len(rows) == int(sq.limit)
+
+	if dir == cursor.Prev {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, "", "", true, nil
+	}
+
+	first, last := rows[0], rows[len(rows)-1]
+
+	if dir == cursor.Prev {
+		next = cursor.EncodePaged(last.CreatedAt, last.ID, cursor.Next)
+		if more {
+			prev = cursor.EncodePaged(first.CreatedAt, first.ID, cursor.Prev)
+		}
+	} else {
+		if more {
+			next = cursor.EncodePaged(last.CreatedAt, last.ID, cursor.Next)
+		}
+		if scan.from {
+			prev = cursor.EncodePaged(first.CreatedAt, first.ID, cursor.Prev)
+		}
 	}
 
-	return time.Unix(0, ns).UTC(), id, true
+	return rows, next, prev, true, nil
 }
@@ -0,0 +1,89 @@
+package listings
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"app.root/db"
+	"app.root/guards"
+	"app.root/httpjson"
+)
+
+// SuggestHandler serves GET /api/listings/suggest?prefix=: frequent
+// words of visible listings starting with prefix, most common first.
+// It reads listing_terms, which jobs.Terms keeps up to date.
+type SuggestHandler struct {
+	DB     *sql.DB
+	Guards []guards.Guard
+}
+
+const (
+	minSuggestRunes = 2
+	maxSuggestRunes = 64
+)
+
+type suggestion struct {
+	Word  string `json:"word"`
+	Count int32  `json:"count"` // listings containing it
+}
+
+type suggestResponse struct {
+	Items []suggestion `json:"items"`
+}
+
+func (h *SuggestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, "INVALID_INPUT", "method not allowed")
+		return
+	}
+
+	for _, g := range h.Guards {
+		if !g.Check(r) {
+			httpjson.Forbidden(w, "RATE_LIMITED", "request blocked")
+			return
+		}
+	}
+
+	// Words are stored as the 'simple' configuration leaves them:
+	// lower case, one word each.
+	prefix := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("prefix")))
+	n := utf8.RuneCountInString(prefix)
+	if n < minSuggestRunes || n > maxSuggestRunes || strings.ContainsAny(prefix, " \t\r\n") {
+		httpjson.BadRequest(w, "INVALID_INPUT", "prefix must be one word of 2 to 64 characters")
+		return
+	}
+
+	limit := int32(10)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 20 {
+			limit = int32(v)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	res, err := db.NewStore(h.DB).SuggestListingTerms(ctx, db.SuggestListingTermsParams{
+		Pattern:  likeEscaper.Replace(prefix) + "%",
+		RowLimit: limit,
+	})
+	if err != nil {
+		httpjson.InternalError(w, "db error")
+		return
+	}
+
+	items := make([]suggestion, 0, len(res))
+	for _, t := range res {
+		items = append(items, suggestion{
+			Word:  t.Word,
+			Count: t.Ndoc,
+		})
+	}
+
+	httpjson.WriteOK(w, suggestResponse{Items: items})
+}
@@ -0,0 +1,34 @@
+package listings
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+/*
+Delete tokens
+
+There are no accounts, so the author proves ownership of a listing
+with a random secret returned once by CreateHandler. Only its sha256
+is stored; 256 bits of entropy make guessing pointless, and the
+delete route carries its own rate limiter on top.
+*/
+
+const deleteTokenBytes = 32
+
+func newDeleteToken() (token string, hash []byte, err error) {
+	b := make([]byte, deleteTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashDeleteToken(token), nil
+}
+
+func hashDeleteToken(token string) []byte {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return sum[:]
+}
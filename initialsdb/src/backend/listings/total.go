@@ -0,0 +1,69 @@
+package listings
+
+import (
+	"context"
+	"time"
+
+	"app.root/db"
+)
+
+/*
+with_total=1: how many public listings match q and the filters.
+
+Up to ExactLimit the count is exact. Past that, or when counting runs
+out of time, the answer is the planner's estimate (EXPLAIN, no scan)
+and is marked approximate. Both together stay within Budget, so the
+page itself is never lost to the handler timeout; if even the
+estimate fails, the total is simply left out. A shadow-banned
+viewer's own rows count, as in the results, so the number does not
+give the ban away. Full-text matches only: fuzzy
+pages have no total.
+*/
+
+type Totals struct {
+	ExactLimit int32         // count exactly up to this many
+	Budget     time.Duration // for counting and estimating together
+}
+
+type searchTotal struct {
+	Count       int64 `json:"count"`
+	Approximate bool  `json:"approximate,omitempty"`
+}
+
+func (t Totals) count(ctx context.Context, store *db.Store, sq searchQuery) *searchTotal {
+	ctx, cancel := context.WithTimeout(ctx, t.Budget)
+	defer cancel()
+
+	arg := db.CountSearchListingsParams{
+		Since:        sq.filter.since,
+		Until:        sq.filter.until,
+		HasLinks:     sq.filter.hasLinks,
+		MinLength:    sq.filter.minLength,
+		MaxLength:    sq.filter.maxLength,
+		MaxLinks:     sq.filter.maxLinks,
+		Q:            sq.q,
+		Web:          sq.web,
+		Lang:         sq.lang,
+		RowCap:       t.ExactLimit + 1,
+		ViewerIpHash: sq.viewer,
+	}
+
+	// A quarter of the budget is kept for the estimate.
+	exactCtx, cancelExact := context.WithTimeout(ctx, t.Budget*3/4)
+	n, err := store.CountSearchListings(exactCtx, arg)
+	cancelExact()
+
+	if err == nil && n <= int64(t.ExactLimit) {
+		return &searchTotal{Count: n}
+	}
+
+	est, err := store.EstimateSearchListings(ctx, arg)
+	if err != nil {
+		return nil
+	}
+
+	// Counting got at least this far, whatever the planner thinks.
+	est = max(est, n)
+
+	return &searchTotal{Count: est, Approximate: true}
+}
@@ -8,6 +8,18 @@ type Listing struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// CreatedListing is the create response. DeleteToken is shown
+// exactly once; the server keeps only its hash.
+type CreatedListing struct {
+	ID          int64      `json:"id"`
+	Body        string     `json:"body"`
+	CreatedAt   time.Time  `json:"created_at"`
+	IsHidden    bool       `json:"is_hidden"`
+	IsPending   bool       `json:"is_pending"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DeleteToken string     `json:"delete_token"`
+}
+
 type Cursor struct {
 	CreatedAt time.Time `json:"created_at"`
 	ID        int64     `json:"id"`
@@ -0,0 +1,77 @@
+package listings
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+syntax=web: q goes through websearch_to_tsquery, which accepts
+"exact phrase", a or b, and -exclusion. Postgres never rejects
+input; it silently drops what it cannot parse. These checks turn
+the common mistakes into INVALID_INPUT instead of surprising results.
+*/
+
+const maxWebQueryLen = 256
+
+func validateWebQuery(q string) error {
+	if q == "" {
+		return errors.New("syntax=web requires q")
+	}
+
+	if utf8.RuneCountInString(q) > maxWebQueryLen {
+		return errors.New("q too long")
+	}
+
+	if strings.Count(q, `"`)%2 != 0 {
+		return errors.New(`unbalanced quote in q`)
+	}
+
+	// Words outside quotes decide the structure; phrase content
+	// is matched literally.
+	var (
+		words    []string
+		positive bool
+	)
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 { // inside quotes
+			if hasTerm(part) {
+				positive = true
+			}
+			words = append(words, "phrase")
+			continue
+		}
+
+		for _, f := range strings.Fields(part) {
+			words = append(words, f)
+			if !strings.HasPrefix(f, "-") && !strings.EqualFold(f, "or") && hasTerm(f) {
+				positive = true
+			}
+		}
+	}
+
+	for i, f := range words {
+		if !strings.EqualFold(f, "or") {
+			continue
+		}
+		if i == 0 || i == len(words)-1 || strings.EqualFold(words[i-1], "or") {
+			return errors.New("OR needs a term on both sides")
+		}
+	}
+
+	// A query made only of exclusions would match (and scan)
+	// nearly everything.
+	if !positive {
+		return errors.New("q needs at least one term that is not excluded")
+	}
+
+	return nil
+}
+
+func hasTerm(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
@@ -0,0 +1,42 @@
+package listings
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateWebQuery(t *testing.T) {
+	tests := []struct {
+		q  string
+		ok bool
+	}{
+		{"bike", true},
+		{`"red bike" -broken`, true},
+		{"bike or scooter", true},
+		{"bike OR scooter -kids", true},
+		{`"red bike" or "blue bike"`, true},
+		{"-broken bike", true},
+		{strings.Repeat("ä", maxWebQueryLen), true},
+
+		{"", false},
+		{strings.Repeat("a", maxWebQueryLen+1), false},
+		{`"red bike`, false},
+		{`"a" "b`, false},
+		{"or bike", false},
+		{"bike or", false},
+		{"bike or or scooter", false},
+		{`"red bike" OR`, false},
+		{"-broken", false},
+		{"-broken -old", false},
+		{"or", false},
+		{`""`, false},
+		{"-- !!", false},
+	}
+
+	for _, tt := range tests {
+		err := validateWebQuery(tt.q)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateWebQuery(%q) = %v, want ok=%v", tt.q, err, tt.ok)
+		}
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"database/sql"
 	"net/http"
 
+	"app.root/admin"
 	"app.root/config"
+	"app.root/content"
+	"app.root/cursor"
 	"app.root/guards"
 	"app.root/listings"
 	"app.root/spa"
@@ -47,24 +50,78 @@ func RegisterRoutes(mux *http.ServeMux, db *sql.DB, cfg *config.Config) {
 		SecretKey:  cfg.ProofOfWork.DecodedSecretKey,
 	}
 
+	// ────────────────────────────────────────
+	// Bans by ip_hash (writes; shadow bans also shape search)
+	// ────────────────────────────────────────
+
+	banGuard := guards.NewBanGuard(guards.BanConfig{
+		Enable:  cfg.Bans.Enable,
+		Salt:    cfg.ServerSalt,
+		Refresh: cfg.Bans.Refresh(),
+	}, db)
+
 	// ────────────────────────────────────────
 	// Listings: search (GET)
 	// ────────────────────────────────────────
 
+	// Deployments from before signed cursors have no CURSOR_SECRET_KEY.
+	cursorKey := cfg.Cursor.DecodedSecretKey
+	if len(cursorKey) == 0 {
+		cursorKey = cursor.DeriveKey(cfg.ServerSalt)
+	}
+
 	mux.Handle("/api/listings/search",
 		&listings.SearchHandler{
-			DB:     db,
-			Guards: guardsCommon,
+			DB:            db,
+			Guards:        guardsCommon,
+			Bans:          banGuard,
+			FuzzyFallback: cfg.Search.FuzzyFallback,
+			Highlight: listings.Highlight{
+				StartSel: cfg.Search.HighlightStartSel,
+				StopSel:  cfg.Search.HighlightStopSel,
+				LongBody: cfg.Search.HighlightLongBody,
+			},
+			Cursors: cursor.NewSigner(cursorKey, cfg.Cursor.TTL()),
+			Totals: listings.Totals{
+				ExactLimit: int32(cfg.Search.TotalExactLimit),
+				Budget:     cfg.Search.TotalBudget(),
+			},
 		},
 	)
 
+	// ────────────────────────────────────────
+	// Listings: suggest (GET), word prefixes from listing_terms
+	// ────────────────────────────────────────
+
+	if cfg.Suggest.Enable {
+		mux.Handle("/api/listings/suggest",
+			&listings.SuggestHandler{
+				DB:     db,
+				Guards: guardsCommon,
+			},
+		)
+	}
+
 	// ────────────────────────────────────────
 	// Listings: create (POST)
 	// ────────────────────────────────────────
 
+	contentFilter, err := content.NewFilter(content.FilterConfig{
+		Enable: cfg.ContentPolicy.Enable,
+		Path:   cfg.ContentPolicy.Path,
+		Reload: cfg.ContentPolicy.Reload(),
+	})
+	if err != nil {
+		panic(err)
+	}
+
 	guardsCreate := append([]guards.Guard{}, guardsCommon...)
 	guardsCreate = append(guardsCreate, bodyGuard...)
 
+	if cfg.Bans.Enable {
+		guardsCreate = append(guardsCreate, banGuard)
+	}
+
 	if cfg.ProofOfWork.Enable {
 		guardsCreate = append(guardsCreate, guards.NewPoWGuard(powCfg))
 		mux.Handle("/pow/challenge", guards.NewPoWHandler(powCfg))
@@ -72,9 +129,69 @@ func RegisterRoutes(mux *http.ServeMux, db *sql.DB, cfg *config.Config) {
 
 	mux.Handle("/api/listings/create",
 		&listings.CreateHandler{
+			DB:      db,
+			Cfg:     cfg,
+			Guards:  guardsCreate,
+			Content: contentFilter,
+			Bans:    banGuard,
+		},
+	)
+
+	// ────────────────────────────────────────
+	// Listings: read (GET), author edit / retraction (PATCH, DELETE)
+	// PATCH/DELETE have their own rate limiter, separate from
+	// guardsCommon, so token guessing is throttled independently
+	// of normal browsing.
+	// ────────────────────────────────────────
+
+	var guardsToken []guards.Guard
+
+	if cfg.DeleteRateLimiter.Enable {
+		guardsToken = append(guardsToken,
+			guards.NewIPRateGuard(guards.IPRateLimiterConfig{
+				Enable:      true,
+				MaxRequests: cfg.DeleteRateLimiter.MaxRequests,
+				Window:      cfg.DeleteRateLimiter.Window(),
+			}),
+		)
+	}
+	guardsToken = append(guardsToken, bodyGuard...)
+
+	item := &listings.ItemHandler{
+		Get: &listings.GetHandler{
 			DB:     db,
-			Cfg:    cfg,
-			Guards: guardsCreate,
+			Guards: guardsCommon,
+			Bans:   banGuard,
+		},
+		Delete: &listings.DeleteHandler{
+			DB:     db,
+			Guards: guardsToken,
+		},
+	}
+
+	if cfg.Edit.Enable {
+		// Banned sources cannot rewrite their listings; retracting
+		// (DELETE) stays open to them.
+		guardsEdit := append([]guards.Guard{}, guardsToken...)
+		if cfg.Bans.Enable {
+			guardsEdit = append(guardsEdit, banGuard)
+		}
+
+		item.Edit = &listings.EditHandler{
+			DB:      db,
+			Cfg:     cfg,
+			Guards:  guardsEdit,
+			Content: contentFilter,
+		}
+	}
+
+	mux.Handle("/api/listings/{id}", item)
+
+	mux.Handle("/api/listings/{id}/revisions",
+		&listings.HistoryHandler{
+			DB:     db,
+			Guards: guardsCommon,
+			Bans:   banGuard,
 		},
 	)
 
@@ -89,10 +206,110 @@ func RegisterRoutes(mux *http.ServeMux, db *sql.DB, cfg *config.Config) {
 		},
 	)
 
+	// ────────────────────────────────────────
+	// Admin: moderation (own guard, not guardsCommon)
+	// ────────────────────────────────────────
+
+	if cfg.Admin.Enable {
+		guardsAdmin := []guards.Guard{
+			guards.NewAdminGuard(guards.AdminConfig{
+				Enable: true,
+				Tokens: cfg.Admin.Tokens,
+			}),
+		}
+
+		mux.Handle("/api/admin/listings/{id}/hide",
+			&admin.HideHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+				Hide:   true,
+			},
+		)
+
+		mux.Handle("/api/admin/listings/{id}/unhide",
+			&admin.HideHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+				Hide:   false,
+			},
+		)
+
+		mux.Handle("/api/admin/listings/search",
+			&admin.SearchHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+			},
+		)
+
+		mux.Handle("/api/admin/listings/bulk-hide",
+			&admin.BulkHideHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+				Salt:   cfg.ServerSalt,
+			},
+		)
+
+		mux.Handle("/api/admin/queue",
+			&admin.QueueHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+			},
+		)
+
+		mux.Handle("/api/admin/queue/{id}/approve",
+			&admin.ReviewHandler{
+				DB:      db,
+				Guards:  guardsAdmin,
+				Approve: true,
+			},
+		)
+
+		mux.Handle("/api/admin/queue/{id}/reject",
+			&admin.ReviewHandler{
+				DB:      db,
+				Guards:  guardsAdmin,
+				Approve: false,
+			},
+		)
+
+		mux.Handle("/api/admin/bans",
+			&admin.BansHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+				Salt:   cfg.ServerSalt,
+				Bans:   banGuard,
+			},
+		)
+
+		mux.Handle("/api/admin/bans/{ip_hash}",
+			&admin.BanHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+				Bans:   banGuard,
+			},
+		)
+
+		mux.Handle("/api/admin/events",
+			&admin.EventsHandler{
+				DB:     db,
+				Guards: guardsAdmin,
+			},
+		)
+	}
+
 	// ────────────────────────────────────────
 	// SPA fallback
 	// ────────────────────────────────────────
 
+	// Permalinks: index.html with the listing's <title> and OpenGraph tags
+	mux.Handle("/l/{id}", spa.SPAHandler{
+		Dir: "web",
+		Page: (&listings.Permalink{
+			DB:   db,
+			Bans: banGuard,
+		}).Page,
+	})
+
 	mux.Handle("/", spa.SPAHandler{
 		Dir: "web",
 	})
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"app.root/admin"
+	"app.root/config"
+	dbpkg "app.root/db"
+	"app.root/jobs"
+)
+
+/*
+Offline moderation over SSH, without raw SQL:
+
+	docker exec <container> /app/server hide -reason spam 42
+
+Same config, same migrations and the same admin operations as the
+HTTP endpoints, so every change lands in moderation_events too
+(actor "cli"). Exit status: 0 ok, 1 failure, 2 usage error.
+*/
+
+const cliActor = "cli"
+
+const cliUsage = `usage: %[1]s <command> [flags] [args]
+
+commands:
+  hide   [-reason r] <id>                      hide a listing
+  unhide [-reason r] <id>                      unhide a listing
+  ban    [-reason r] [-for 24h] [-shadow] <ip|ip_hash>
+  unban  <ip|ip_hash>
+  stats                                        listing and ban counts
+  purge  -older-than 30d                       delete listings hidden longer than that
+
+without a command the HTTP server starts.
+`
+
+type command func(ctx context.Context, store *dbpkg.Store, cfg *config.Config, args []string) error
+
+var commands = map[string]command{
+	"hide":   cmdHide("hide", true),
+	"unhide": cmdHide("unhide", false),
+	"ban":    cmdBan,
+	"unban":  cmdUnban,
+	"stats":  cmdStats,
+	"purge":  cmdPurge,
+}
+
+// errUsage marks bad arguments (exit status 2).
+var errUsage = errors.New("usage")
+
+func runCommand(args []string) int {
+	name := args[0]
+
+	cmd, ok := commands[name]
+	if !ok {
+		if name != "help" && name != "-h" && name != "--help" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		}
+		fmt.Fprintf(os.Stderr, cliUsage, filepath.Base(os.Args[0]))
+		return 2
+	}
+
+	cfg := config.LoadConfig()
+
+	db, err := openDB(cfg.DBDSN)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := dbpkg.RunMigrations(db); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	err = cmd(ctx, dbpkg.NewStore(db), &cfg, args[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "%s: %v\n\n", name, err)
+		fmt.Fprintf(os.Stderr, cliUsage, filepath.Base(os.Args[0]))
+		return 2
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+
+	return 0
+}
+
+//
+// ──────────────────────────────────────────────
+// Listings
+// ──────────────────────────────────────────────
+//
+
+func cmdHide(name string, hide bool) command {
+	return func(ctx context.Context, store *dbpkg.Store, _ *config.Config, args []string) error {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		reason := fs.String("reason", "", "reason recorded in the audit log")
+		if err := fs.Parse(args); err != nil {
+			return fmt.Errorf("%w: %v", errUsage, err)
+		}
+
+		if fs.NArg() != 1 {
+			return fmt.Errorf("%w: expected one listing id", errUsage)
+		}
+		id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("%w: invalid listing id %q", errUsage, fs.Arg(0))
+		}
+
+		state, err := admin.SetHidden(ctx, store, id, hide, cliActor, strings.TrimSpace(*reason))
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("listing %d not found", id)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("listing %d: is_hidden=%t\n", state.ID, state.IsHidden)
+		return nil
+	}
+}
+
+func cmdStats(ctx context.Context, store *dbpkg.Store, _ *config.Config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: stats takes no arguments", errUsage)
+	}
+
+	listings, err := store.ListingStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	bans, err := store.CountActiveBans(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("listings:  %d total, %d visible, %d hidden, %d pending, %d in the last 24h\n",
+		listings.Total, listings.Visible, listings.Hidden, listings.Pending, listings.LastDay)
+	fmt.Printf("bans:      %d active (%d shadow)\n", bans.Total, bans.Shadow)
+	return nil
+}
+
+func cmdPurge(ctx context.Context, store *dbpkg.Store, _ *config.Config, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	olderThan := fs.String("older-than", "", "hidden for at least this long, e.g. 30d or 12h")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	if fs.NArg() != 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		return fmt.Errorf("%w: -older-than: %v", errUsage, err)
+	}
+
+	// Same batched purge as the retention job, IP half disabled,
+	// but every deleted listing is logged.
+	return jobs.Retention{
+		DB:               store.DB(),
+		PurgeHiddenAfter: age,
+		AuditActor:       cliActor,
+	}.Run(ctx)
+}
+
+//
+// ──────────────────────────────────────────────
+// Bans
+// ──────────────────────────────────────────────
+//
+
+func cmdBan(ctx context.Context, store *dbpkg.Store, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("ban", flag.ContinueOnError)
+	reason := fs.String("reason", "", "reason recorded with the ban")
+	duration := fs.String("for", "", "ban length, e.g. 7d or 12h (default permanent)")
+	shadow := fs.Bool("shadow", false, "shadow ban: posts are stored hidden")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	ipHash, err := ipHashArg(fs, cfg)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt sql.NullTime
+	if *duration != "" {
+		d, err := parseAge(*duration)
+		if err != nil {
+			return fmt.Errorf("%w: -for: %v", errUsage, err)
+		}
+		expiresAt = sql.NullTime{Time: time.Now().Add(d), Valid: true}
+	}
+
+	ban, err := admin.Ban(ctx, store, dbpkg.UpsertBanParams{
+		IpHash:    ipHash,
+		Reason:    strings.TrimSpace(*reason),
+		Actor:     cliActor,
+		ExpiresAt: expiresAt,
+		Shadow:    *shadow,
+	})
+	if err != nil {
+		return err
+	}
+
+	until := "permanent"
+	if ban.ExpiresAt.Valid {
+		until = "until " + ban.ExpiresAt.Time.Format(time.RFC3339)
+	}
+	fmt.Printf("banned %x (%s, shadow=%t)\n", ban.IpHash, until, ban.Shadow)
+	fmt.Println("running servers pick this up on their next ban refresh")
+	return nil
+}
+
+func cmdUnban(ctx context.Context, store *dbpkg.Store, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("unban", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	ipHash, err := ipHashArg(fs, cfg)
+	if err != nil {
+		return err
+	}
+
+	err = admin.Unban(ctx, store, ipHash, cliActor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no ban for %x", ipHash)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("unbanned %x\n", ipHash)
+	return nil
+}
+
+//
+// ──────────────────────────────────────────────
+// Helpers
+// ──────────────────────────────────────────────
+//
+
+func ipHashArg(fs *flag.FlagSet, cfg *config.Config) ([]byte, error) {
+	if fs.NArg() != 1 {
+		return nil, fmt.Errorf("%w: expected one ip or ip_hash", errUsage)
+	}
+
+	ipHash, ok := admin.ParseIPOrHash(fs.Arg(0), cfg.ServerSalt)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an ip or hex ip_hash: %q", errUsage, fs.Arg(0))
+	}
+	return ipHash, nil
+}
+
+// parseAge is time.ParseDuration plus a whole-day suffix ("30d").
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("duration required")
+	}
+
+	var (
+		d   time.Duration
+		err error
+	)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -13,10 +13,18 @@ import (
 
 	"app.root/config"
 	dbpkg "app.root/db"
+	"app.root/jobs"
 	"app.root/routes"
 )
 
 func main() {
+	// -----------------------------------------------------
+	// Offline moderation: server <command> [flags] [args]
+	// -----------------------------------------------------
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	fmt.Println("app.root starting")
 
 	// -----------------------------------------------------
@@ -27,48 +35,50 @@ func main() {
 	fmt.Println("Configuration loaded.")
 
 	// -----------------------------------------------------
-	// Database (retry loop)
+	// Database (retry loop) + migrations
 	// -----------------------------------------------------
-	var db *sql.DB
-	var err error
-
-	for i := 1; i <= 15; i++ {
-		db, err = sql.Open("pgx", cfg.DBDSN)
-		if err == nil {
-			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-			err = db.PingContext(ctx)
-			cancel()
-		}
-
-		if err == nil {
-			break
-		}
-
-		fmt.Printf("database not ready (attempt %d/15): %v\n", i, err)
-		time.Sleep(1 * time.Second)
-	}
-
+	db, err := openDB(cfg.DBDSN)
 	if err != nil {
-		panic(fmt.Errorf("database connection failed after retries: %w", err))
+		panic(err)
 	}
-
 	defer db.Close()
 
-	db.SetMaxOpenConns(20)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(30 * time.Minute)
-
 	fmt.Println("database ready")
 
-	// -----------------------------------------------------
-	// Migrations
-	// -----------------------------------------------------
 	if err := dbpkg.RunMigrations(db); err != nil {
 		panic(err)
 	}
 
 	fmt.Println("migrations OK")
 
+	// -----------------------------------------------------
+	// Background jobs (stopped on shutdown)
+	// -----------------------------------------------------
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	if cfg.Retention.Enable {
+		retention := jobs.Retention{
+			DB:               db,
+			PurgeHiddenAfter: cfg.Retention.PurgeHiddenAfter(),
+			AnonymizeIPAfter: cfg.Retention.AnonymizeIPAfter(),
+		}
+		go jobs.Every(jobsCtx, "retention", cfg.Retention.Interval(), retention.Run)
+	}
+
+	if cfg.Expiry.Enable {
+		expiry := jobs.Expiry{DB: db}
+		go jobs.Every(jobsCtx, "expiry", cfg.Expiry.Interval(), expiry.Run)
+	}
+
+	if cfg.Suggest.Enable {
+		terms := jobs.Terms{
+			DB:      db,
+			MinDocs: int32(cfg.Suggest.MinDocs),
+		}
+		go jobs.Every(jobsCtx, "terms", cfg.Suggest.Refresh(), terms.Run)
+	}
+
 	// -----------------------------------------------------
 	// HTTP server
 	// -----------------------------------------------------
@@ -98,6 +108,7 @@ func main() {
 
 	<-sigCtx.Done()
 	fmt.Println("shutting down")
+	stopJobs()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -106,3 +117,36 @@ func main() {
 
 	fmt.Println("bye")
 }
+
+// openDB connects with retries (the database container may still be
+// starting) and sets the pool limits.
+func openDB(dsn string) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+
+	for i := 1; i <= 15; i++ {
+		db, err = sql.Open("pgx", dsn)
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			err = db.PingContext(ctx)
+			cancel()
+		}
+
+		if err == nil {
+			break
+		}
+
+		fmt.Printf("database not ready (attempt %d/15): %v\n", i, err)
+		time.Sleep(1 * time.Second)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("database connection failed after retries: %w", err)
+	}
+
+	db.SetMaxOpenConns(20)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	return db, nil
+}
@@ -1,15 +1,35 @@
 package spa
 
 import (
+	"html"
 	"net/http"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 type SPAHandler struct {
 	Dir string
+
+	// Page, when set, describes a deep link (e.g. /l/{id}): index.html
+	// is served with its <title> and OpenGraph tags. ok=false serves
+	// the plain index.html with 404, the app shows "not found".
+	Page func(r *http.Request) (page Page, ok bool)
+}
+
+// Page is the <head> metadata injected for a deep link.
+type Page struct {
+	Title       string
+	Description string
 }
 
 func (h SPAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Page != nil {
+		h.serveDeepLink(w, r)
+		return
+	}
+
 	// Try to open the requested file
 	if _, err := http.Dir(h.Dir).Open(r.URL.Path); err == nil {
 		http.FileServer(http.Dir(h.Dir)).ServeHTTP(w, r)
@@ -19,3 +39,58 @@ func (h SPAHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Fallback to index.html for client-side routing
 	http.ServeFile(w, r, filepath.Join(h.Dir, "index.html"))
 }
+
+var titleTag = regexp.MustCompile(`(?s)<title>(.*?)</title>`)
+
+func (h SPAHandler) serveDeepLink(w http.ResponseWriter, r *http.Request) {
+	index, err := os.ReadFile(filepath.Join(h.Dir, "index.html"))
+	if err != nil {
+		http.Error(w, "index.html missing", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	page, ok := h.Page(r)
+	if !ok {
+		status = http.StatusNotFound
+	} else {
+		index = injectMeta(index, page)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	_, _ = w.Write(index)
+}
+
+// injectMeta swaps the <title> and adds OpenGraph / Twitter tags next
+// to it. The original title becomes og:site_name.
+func injectMeta(index []byte, page Page) []byte {
+	m := titleTag.FindSubmatchIndex(index)
+	if m == nil {
+		return index
+	}
+
+	// <title> text may already hold entities: decode, then escape once.
+	site := html.EscapeString(html.UnescapeString(strings.TrimSpace(string(index[m[2]:m[3]]))))
+	title := html.EscapeString(page.Title)
+	desc := html.EscapeString(page.Description)
+
+	var b strings.Builder
+	b.WriteString("<title>" + title + "</title>")
+	meta := func(attr, key, value string) {
+		b.WriteString("\n    <meta " + attr + `="` + key + `" content="` + value + `" />`)
+	}
+	meta("name", "description", desc)
+	meta("property", "og:type", "article")
+	meta("property", "og:site_name", site)
+	meta("property", "og:title", title)
+	meta("property", "og:description", desc)
+	meta("name", "twitter:card", "summary")
+
+	out := make([]byte, 0, len(index)+b.Len())
+	out = append(out, index[:m[0]]...)
+	out = append(out, b.String()...)
+	out = append(out, index[m[1]:]...)
+	return out
+}
@@ -14,9 +14,13 @@ interface Listing {
   created_at: string
 }
 
+type SearchMode = 'fts' | 'fuzzy'
+
 interface SearchResponse {
   items: Listing[]
   next_cursor?: string
+  mode: SearchMode
+  total?: { count: number; approximate?: boolean }
 }
 
 type StatusType = 'info' | 'error'
@@ -30,7 +34,8 @@ interface StatusMessage {
 type AppState =
   | { tag: 'idle' }
   | { tag: 'searching' }
-  | { tag: 'search'; cursor: string | null }
+  // q is the query the cursor belongs to, not the live input.
+  | { tag: 'search'; q: string; cursor: string | null; mode: SearchMode }
   | { tag: 'posting' }
   | { tag: 'pow' }
 
@@ -46,11 +51,16 @@ async function searchAPI(
   q: string,
   limit: number,
   cursor: string | null,
+  mode: SearchMode | null = null,
+  withTotal = false,
 ): Promise<SearchResponse> {
   const params = new URLSearchParams()
   params.set('q', q)
   params.set('limit', String(limit))
   if (cursor) params.set('cursor', cursor)
+  if (withTotal) params.set('with_total', '1')
+  // A fuzzy fallback page continues in fuzzy mode.
+  if (mode) params.set('mode', mode)
 
   const res = await fetch(`/api/listings/search?${params.toString()}`)
   if (!res.ok) throw new Error('search failed')
@@ -76,6 +86,12 @@ async function postAPI(
   return res.json()
 }
 
+async function fetchListing(id: string): Promise<Listing> {
+  const res = await fetch(`/api/listings/${id}`)
+  if (!res.ok) throw new Error('listing failed')
+  return res.json()
+}
+
 async function fetchCount(): Promise<number> {
   const res = await fetch('/api/listings/count')
   if (!res.ok) throw new Error('count failed')
@@ -306,6 +322,16 @@ export default function App() {
       .catch(() => {})
   }, [])
 
+  // Permalink: /l/{id} opens with that one listing.
+  useEffect(() => {
+    const m = window.location.pathname.match(/^\/l\/(\d+)$/)
+    if (!m) return
+
+    fetchListing(m[1])
+      .then((l) => setItems([l]))
+      .catch(() => pushStatus('Listing not found.', 'error'))
+  }, [])
+
   function pushStatus(text: string, type: StatusType) {
     setStatusQueue((q) => [...q, { id: Date.now(), text, type }])
   }
@@ -331,10 +357,19 @@ export default function App() {
     setState({ tag: 'searching' })
 
     try {
-      const res = await searchAPI(query, PAGE_SIZE, null)
+      const res = await searchAPI(query, PAGE_SIZE, null, null, true)
       setItems(res.items)
-      setState({ tag: 'search', cursor: res.next_cursor ?? null })
-      pushStatus(`Results: ${res.items.length}`, 'info')
+      setState({
+        tag: 'search',
+        q: query,
+        cursor: res.next_cursor ?? null,
+        mode: res.mode,
+      })
+      // Large totals are estimates, shown as ~N.
+      const total = res.total
+        ? `${res.total.approximate ? '~' : ''}${res.total.count.toLocaleString()}`
+        : String(res.items.length)
+      pushStatus(`Results: ${total}`, 'info')
     } catch {
       setState({ tag: 'idle' })
       pushStatus('Search failed.', 'error')
@@ -347,11 +382,16 @@ export default function App() {
     setState({ tag: 'searching' })
 
     try {
-      const res = await searchAPI(query, PAGE_SIZE, state.cursor)
+      const res = await searchAPI(state.q, PAGE_SIZE, state.cursor, state.mode)
       setItems((prev) => [...prev, ...res.items])
-      setState({ tag: 'search', cursor: res.next_cursor ?? null })
+      setState({
+        tag: 'search',
+        q: state.q,
+        cursor: res.next_cursor ?? null,
+        mode: res.mode,
+      })
     } catch {
-      setState({ tag: 'search', cursor: state.cursor })
+      setState(state)
       pushStatus('Search failed.', 'error')
     }
   }
@@ -0,0 +1,32 @@
+-- -----------------------------------------------------
+-- MODERATION AUDIT LOG
+-- -----------------------------------------------------
+-- listing_id / ip_hash are plain values, not foreign keys:
+-- the log must outlive the rows it talks about.
+CREATE TABLE moderation_events (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+
+    -- subject (at least one of them is set)
+    listing_id BIGINT,
+    ip_hash BYTEA,
+
+    -- who / what / why
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- -----------------------------------------------------
+-- INDEXES
+-- -----------------------------------------------------
+
+-- Keyset pagination, newest first
+CREATE INDEX idx_moderation_events_created_at_id
+ON moderation_events (created_at DESC, id DESC);
+
+-- History of a single listing
+CREATE INDEX idx_moderation_events_listing_id_created_at
+ON moderation_events (listing_id, created_at DESC, id DESC)
+WHERE listing_id IS NOT NULL;
@@ -0,0 +1,20 @@
+-- -----------------------------------------------------
+-- BANS (by ip_hash)
+-- -----------------------------------------------------
+CREATE TABLE bans (
+    ip_hash BYTEA PRIMARY KEY, -- sha256(ip + server_salt), same as listings.ip_hash
+
+    reason TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ -- NULL = permanent
+);
+
+-- -----------------------------------------------------
+-- INDEXES
+-- -----------------------------------------------------
+
+-- Periodic refresh of active bans
+CREATE INDEX idx_bans_expires_at
+ON bans (expires_at);
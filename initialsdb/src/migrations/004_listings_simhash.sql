@@ -0,0 +1,15 @@
+-- -----------------------------------------------------
+-- NEAR-DUPLICATE DETECTION
+-- -----------------------------------------------------
+
+-- 64-bit SimHash of the normalized body (see content.SimHash).
+-- NULL for bodies too short to fingerprint and for rows created
+-- before this migration.
+ALTER TABLE listings
+ADD COLUMN simhash BIGINT;
+
+-- Recent-window scan for the Hamming distance check, index-only
+CREATE INDEX idx_listings_simhash_created_at
+ON listings (created_at DESC)
+INCLUDE (simhash)
+WHERE simhash IS NOT NULL;
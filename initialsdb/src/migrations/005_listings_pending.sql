@@ -0,0 +1,13 @@
+-- -----------------------------------------------------
+-- PRE-MODERATION QUEUE
+-- -----------------------------------------------------
+
+-- Held for review: not public until approved.
+-- Visible = is_hidden = FALSE AND is_pending = FALSE.
+ALTER TABLE listings
+ADD COLUMN is_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Review queue, oldest first
+CREATE INDEX idx_listings_pending_created_at_id
+ON listings (created_at, id)
+WHERE is_pending = TRUE;
@@ -0,0 +1,17 @@
+-- -----------------------------------------------------
+-- SHADOW BANS
+-- -----------------------------------------------------
+
+-- A shadow-banned source may keep posting; its listings are stored
+-- hidden but still shown to that same ip_hash.
+ALTER TABLE bans
+ADD COLUMN shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Hidden because of a shadow ban (cleared on unhide).
+ALTER TABLE listings
+ADD COLUMN is_shadowed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The viewer's own shadowed listings, merged into search pages
+CREATE INDEX idx_listings_shadowed_ip_hash_created_at_id
+ON listings (ip_hash, created_at DESC, id DESC)
+WHERE is_shadowed = TRUE;
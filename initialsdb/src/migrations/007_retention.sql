@@ -0,0 +1,12 @@
+-- -----------------------------------------------------
+-- RETENTION
+-- -----------------------------------------------------
+
+-- Old listings lose their ip_hash (set to NULL by the retention job).
+ALTER TABLE listings
+ALTER COLUMN ip_hash DROP NOT NULL;
+
+-- Rows still carrying an ip_hash, oldest first
+CREATE INDEX idx_listings_ip_hash_present_created_at
+ON listings (created_at)
+WHERE ip_hash IS NOT NULL;
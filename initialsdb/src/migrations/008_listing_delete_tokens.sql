@@ -0,0 +1,8 @@
+-- -----------------------------------------------------
+-- AUTHOR RETRACTION TOKENS
+-- -----------------------------------------------------
+
+-- sha256 of the one-time secret handed to the author on create;
+-- the token itself is never stored. NULL once used.
+ALTER TABLE listings
+ADD COLUMN delete_token_hash BYTEA;
@@ -0,0 +1,19 @@
+-- -----------------------------------------------------
+-- LISTING EDITS
+-- -----------------------------------------------------
+
+-- Set on every author edit; body_tsv follows body automatically.
+ALTER TABLE listings
+ADD COLUMN edited_at TIMESTAMPTZ;
+
+-- One row per superseded body: created_at is when it was replaced.
+CREATE TABLE listing_revisions (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    listing_id BIGINT NOT NULL REFERENCES listings (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- History per listing, newest first
+CREATE INDEX idx_listing_revisions_listing_id
+ON listing_revisions (listing_id, id DESC);
@@ -0,0 +1,13 @@
+-- -----------------------------------------------------
+-- PER-LISTING EXPIRY
+-- -----------------------------------------------------
+
+-- NULL = never expires. Reads filter expired rows right away;
+-- the reaper job hides them in the background.
+ALTER TABLE listings
+ADD COLUMN expires_at TIMESTAMPTZ;
+
+-- Reaper scan: visible rows that carry an expiry
+CREATE INDEX idx_listings_expires_at
+ON listings (expires_at)
+WHERE expires_at IS NOT NULL AND is_hidden = FALSE;
@@ -0,0 +1,19 @@
+-- -----------------------------------------------------
+-- SEARCH QUERY PARSING
+-- -----------------------------------------------------
+
+-- One place for the q -> tsquery step shared by every search query.
+-- web = TRUE: websearch_to_tsquery ("phrase", or, -exclude);
+-- otherwise plainto_tsquery (all words ANDed).
+-- Inlinable SQL function, so the planner still sees a constant.
+CREATE FUNCTION listing_tsquery(q text, web boolean)
+RETURNS tsquery
+LANGUAGE sql
+IMMUTABLE
+PARALLEL SAFE
+AS $$
+    SELECT CASE
+        WHEN web THEN websearch_to_tsquery('simple', q)
+        ELSE plainto_tsquery('simple', q)
+    END
+$$;
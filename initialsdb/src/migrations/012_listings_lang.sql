@@ -0,0 +1,111 @@
+-- -----------------------------------------------------
+-- LANGUAGE-AWARE FULL TEXT SEARCH
+-- -----------------------------------------------------
+
+-- body_tsv becomes to_tsvector(lang, body) || to_tsvector('simple', body):
+-- stems for the listing's language, plus the exact words every
+-- search matched before. A generated expression cannot be altered,
+-- so the column (and its GIN index) is dropped and rebuilt after
+-- the backfill below. This rewrites the table under an exclusive
+-- lock: minutes on a large one (see migrationTimeout in
+-- db/migrations.go), and the vectors and index roughly double.
+ALTER TABLE listings
+DROP COLUMN body_tsv;
+
+ALTER TABLE listings
+ADD COLUMN lang regconfig NOT NULL DEFAULT 'simple';
+
+-- -----------------------------------------------------
+-- BACKFILL (same rules as content.DetectLang)
+-- -----------------------------------------------------
+
+-- Mostly Cyrillic letters -> russian
+UPDATE listings
+SET lang = 'russian'
+WHERE
+    length(regexp_replace(body, '[^А-Яа-яЁё]', '', 'g')) * 2
+    > length(regexp_replace(body, '[^[:alpha:]]', '', 'g'));
+
+-- Otherwise the language with the most stopword hits (at least 2,
+-- no tie); everything else stays 'simple'.
+WITH stopwords (cfg, word) AS (
+    VALUES
+        ('english', 'the'), ('english', 'and'), ('english', 'with'), ('english', 'for'), ('english', 'is'),
+        ('english', 'are'), ('english', 'this'), ('english', 'that'), ('english', 'of'), ('english', 'to'),
+        ('german', 'der'), ('german', 'die'), ('german', 'das'), ('german', 'und'), ('german', 'ist'),
+        ('german', 'nicht'), ('german', 'mit'), ('german', 'ein'), ('german', 'eine'), ('german', 'für'),
+        ('french', 'le'), ('french', 'la'), ('french', 'les'), ('french', 'et'), ('french', 'est'),
+        ('french', 'une'), ('french', 'des'), ('french', 'pour'), ('french', 'avec'), ('french', 'dans'),
+        ('spanish', 'el'), ('spanish', 'los'), ('spanish', 'las'), ('spanish', 'y'), ('spanish', 'es'),
+        ('spanish', 'una'), ('spanish', 'para'), ('spanish', 'con'), ('spanish', 'del'), ('spanish', 'por'),
+        ('italian', 'il'), ('italian', 'gli'), ('italian', 'della'), ('italian', 'che'), ('italian', 'è'),
+        ('italian', 'una'), ('italian', 'per'), ('italian', 'con'), ('italian', 'sono'), ('italian', 'non'),
+        ('portuguese', 'o'), ('portuguese', 'os'), ('portuguese', 'as'), ('portuguese', 'e'), ('portuguese', 'é'),
+        ('portuguese', 'uma'), ('portuguese', 'para'), ('portuguese', 'com'), ('portuguese', 'não'), ('portuguese', 'do'),
+        ('dutch', 'de'), ('dutch', 'het'), ('dutch', 'een'), ('dutch', 'en'), ('dutch', 'is'),
+        ('dutch', 'van'), ('dutch', 'voor'), ('dutch', 'met'), ('dutch', 'niet'), ('dutch', 'op')
+),
+words AS (
+    SELECT l.id, w.word
+    FROM listings l,
+        regexp_split_to_table(lower(l.body), '[^[:alpha:]]+') AS w (word)
+    WHERE l.lang = 'simple'::regconfig
+),
+hits AS (
+    SELECT words.id, stopwords.cfg, COUNT(*) AS n
+    FROM words
+    JOIN stopwords ON stopwords.word = words.word
+    GROUP BY words.id, stopwords.cfg
+),
+best AS (
+    SELECT DISTINCT ON (id)
+        id,
+        cfg,
+        n,
+        COUNT(*) OVER (PARTITION BY id, n) AS same
+    FROM hits
+    ORDER BY id, n DESC
+)
+UPDATE listings l
+SET lang = best.cfg::regconfig
+FROM best
+WHERE
+    l.id = best.id
+    AND best.n >= 2
+    AND best.same = 1;
+
+-- -----------------------------------------------------
+-- REBUILD body_tsv + INDEX
+-- -----------------------------------------------------
+
+ALTER TABLE listings
+ADD COLUMN body_tsv tsvector GENERATED ALWAYS AS (
+    to_tsvector(lang, body) || to_tsvector('simple', body)
+) STORED;
+
+CREATE INDEX idx_listings_visible_fts
+ON listings
+USING GIN (body_tsv)
+WHERE is_hidden = FALSE;
+
+-- -----------------------------------------------------
+-- QUERY PARSING WITH A LANGUAGE
+-- -----------------------------------------------------
+
+-- Same as before, plus the query in the requested configuration,
+-- ORed with 'simple' so exact words still match in any listing.
+-- STABLE rather than IMMUTABLE: text -> regconfig depends on the
+-- search path. Still inlined by the planner.
+DROP FUNCTION listing_tsquery(text, boolean);
+
+CREATE FUNCTION listing_tsquery(q text, web boolean, lang text)
+RETURNS tsquery
+LANGUAGE sql
+STABLE
+PARALLEL SAFE
+AS $$
+    SELECT CASE
+        WHEN web THEN websearch_to_tsquery(lang::regconfig, q) || websearch_to_tsquery('simple', q)
+        ELSE plainto_tsquery(lang::regconfig, q) || plainto_tsquery('simple', q)
+    END
+$$;
@@ -0,0 +1,11 @@
+-- -----------------------------------------------------
+-- FUZZY / SUBSTRING SEARCH (pg_trgm)
+-- -----------------------------------------------------
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Serves both word_similarity (<%) and ILIKE '%...%' on visible rows
+CREATE INDEX idx_listings_visible_body_trgm
+ON listings
+USING GIN (body gin_trgm_ops)
+WHERE is_hidden = FALSE;
@@ -0,0 +1,15 @@
+-- -----------------------------------------------------
+-- TERM STATISTICS (search suggestions)
+-- -----------------------------------------------------
+
+-- Word counts over visible listings, rebuilt by the terms job with
+-- ts_stat. /api/listings/suggest reads only this table.
+CREATE TABLE listing_terms (
+    word TEXT PRIMARY KEY,
+    ndoc INTEGER NOT NULL,  -- listings containing the word
+    nentry INTEGER NOT NULL -- occurrences in total
+);
+
+-- Prefix lookups (word LIKE 'pre%') under any database collation
+CREATE INDEX idx_listing_terms_word_prefix
+ON listing_terms (word text_pattern_ops);